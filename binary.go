@@ -0,0 +1,372 @@
+package github
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// BinaryPatchMethod identifies how the data of a binary patch fragment is
+// encoded.
+type BinaryPatchMethod int
+
+const (
+	// BinaryLiteral fragments contain the complete contents of the file.
+	BinaryLiteral BinaryPatchMethod = iota
+
+	// BinaryDelta fragments contain git delta instructions that must be
+	// applied to the original contents of the file.
+	BinaryDelta
+)
+
+// String returns a human-readable name for the binary patch method.
+func (m BinaryPatchMethod) String() string {
+	switch m {
+	case BinaryLiteral:
+		return "literal"
+	case BinaryDelta:
+		return "delta"
+	default:
+		return "unknown"
+	}
+}
+
+// BinaryPatch is the decoded form of a "GIT binary patch" section, which
+// git emits for binary files when diffs are generated with --binary.
+type BinaryPatch struct {
+	// Forward transforms the old file contents into the new contents.
+	Forward *BinaryFragment
+
+	// Reverse transforms the new file contents back into the old contents.
+	// It is nil if the patch did not include a reverse fragment.
+	Reverse *BinaryFragment
+}
+
+// BinaryFragment is a single "literal" or "delta" block of a binary patch.
+type BinaryFragment struct {
+	// Method indicates whether Data holds a literal file or a delta.
+	Method BinaryPatchMethod
+
+	// Size is the number of bytes in Data, as declared by the fragment
+	// header.
+	Size int64
+
+	// Data is the base85-decoded and inflated payload of the fragment.
+	Data []byte
+}
+
+// Apply returns the file contents produced by the fragment. Literal
+// fragments return their data unchanged and ignore base; delta fragments
+// are applied to base, which must be the contents the delta was computed
+// against.
+//
+// Parameters:
+//   - base: The original file contents. Only used for delta fragments.
+//
+// Returns:
+//   - A byte slice containing the resulting file contents.
+//   - An error if the delta is malformed or does not match base.
+//
+// Example:
+//
+//	newBlob, err := gitDiff.BinaryPatch.Forward.Apply(oldBlob)
+//	if err != nil {
+//	  // Handle error
+//	}
+//	// Use newBlob as the new contents of the binary file
+func (f *BinaryFragment) Apply(base []byte) ([]byte, error) {
+	if f.Method == BinaryLiteral {
+		return f.Data, nil
+	}
+
+	return applyDelta(base, f.Data)
+}
+
+// parseBinaryContents marks gitDiff as binary if its content lines contain
+// a "Binary files ... differ" line or a "GIT binary patch" marker, and
+// decodes the binary patch that follows the marker.
+func parseBinaryContents(lines []string, gitDiff *GitDiff) error {
+	for i, line := range lines {
+		switch {
+		case isBinaryFilesLine(line):
+			gitDiff.IsBinary = true
+
+			return nil
+		case line == "GIT binary patch":
+			patch, err := parseBinaryPatch(lines[i+1:])
+			if err != nil {
+				return err
+			}
+
+			gitDiff.IsBinary = true
+			gitDiff.BinaryPatch = patch
+
+			return nil
+		case strings.HasPrefix(line, "@@ "):
+			// Text diffs never contain binary markers after a hunk.
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// isBinaryFilesLine reports whether a line is git's placeholder for a
+// binary file diff generated without --binary.
+func isBinaryFilesLine(line string) bool {
+	return strings.HasPrefix(line, "Binary files ") && strings.HasSuffix(line, " differ")
+}
+
+// parseBinaryPatch decodes the lines following a "GIT binary patch"
+// marker into a BinaryPatch. The forward fragment is required; the
+// reverse fragment is optional.
+func parseBinaryPatch(lines []string) (*BinaryPatch, error) {
+	forward, rest, err := parseBinaryFragment(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := &BinaryPatch{Forward: forward}
+
+	if len(rest) > 0 {
+		reverse, _, err := parseBinaryFragment(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		patch.Reverse = reverse
+	}
+
+	return patch, nil
+}
+
+// parseBinaryFragment decodes a single fragment starting at lines[0],
+// which must be a "literal <size>" or "delta <size>" header. The payload
+// ends at the first empty line or at the end of the input. It returns the
+// fragment along with the lines following it.
+func parseBinaryFragment(lines []string) (*BinaryFragment, []string, error) {
+	if len(lines) == 0 {
+		return nil, nil, errors.New("missing binary patch data")
+	}
+
+	fields := strings.Fields(lines[0])
+	if len(fields) != 2 {
+		return nil, nil, errors.New("invalid binary patch header")
+	}
+
+	fragment := &BinaryFragment{}
+
+	switch fields[0] {
+	case "literal":
+		fragment.Method = BinaryLiteral
+	case "delta":
+		fragment.Method = BinaryDelta
+	default:
+		return nil, nil, errors.New("invalid binary patch header")
+	}
+
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, nil, errors.New("invalid binary patch size")
+	}
+
+	fragment.Size = size
+
+	var compressed []byte
+
+	i := 1
+	for ; i < len(lines) && lines[i] != ""; i++ {
+		decoded, err := decodeBase85Line(lines[i])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		compressed = append(compressed, decoded...)
+	}
+
+	// Skip the blank lines separating fragments.
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid binary patch data: %w", err)
+	}
+
+	// Read one byte past the declared size so that oversized payloads are
+	// detected without inflating all of them.
+	fragment.Data, err = io.ReadAll(io.LimitReader(reader, size+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid binary patch data: %w", err)
+	}
+
+	if int64(len(fragment.Data)) != size {
+		return nil, nil, errors.New("binary patch size mismatch")
+	}
+
+	return fragment, lines[i:], nil
+}
+
+// base85Alphabet is the character set used by git's base85 encoding,
+// which differs from both Ascii85 and RFC 1924.
+const base85Alphabet = "0123456789" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+	"abcdefghijklmnopqrstuvwxyz" +
+	"!#$%&()*+-;<=>?@^_`{|}~"
+
+// decodeBase85Line decodes one line of a binary patch payload. The first
+// character encodes the number of decoded bytes on the line ('A'-'Z' for
+// 1-26, 'a'-'z' for 27-52), and the rest encodes them in groups of five
+// characters per four bytes.
+func decodeBase85Line(line string) ([]byte, error) {
+	if len(line) < 6 || (len(line)-1)%5 != 0 {
+		return nil, errors.New("invalid binary patch line")
+	}
+
+	var length int
+
+	switch c := line[0]; {
+	case c >= 'A' && c <= 'Z':
+		length = int(c-'A') + 1
+	case c >= 'a' && c <= 'z':
+		length = int(c-'a') + 27
+	default:
+		return nil, errors.New("invalid binary patch line length")
+	}
+
+	encoded := line[1:]
+	decoded := make([]byte, 0, len(encoded)/5*4)
+
+	for i := 0; i < len(encoded); i += 5 {
+		var acc uint64
+
+		for _, c := range []byte(encoded[i : i+5]) {
+			value := strings.IndexByte(base85Alphabet, c)
+			if value < 0 {
+				return nil, errors.New("invalid base85 character")
+			}
+
+			acc = acc*85 + uint64(value)
+		}
+
+		if acc > 0xffffffff {
+			return nil, errors.New("invalid base85 sequence")
+		}
+
+		decoded = append(decoded, byte(acc>>24), byte(acc>>16), byte(acc>>8), byte(acc))
+	}
+
+	if length > len(decoded) {
+		return nil, errors.New("invalid binary patch line length")
+	}
+
+	return decoded[:length], nil
+}
+
+// applyDelta applies git delta instructions to base. A delta starts with
+// the expected source and target sizes, followed by a sequence of copy
+// instructions (copying a range of base) and insert instructions
+// (carrying literal bytes).
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, delta, err := readDeltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	if srcSize != uint64(len(base)) {
+		return nil, errors.New("delta base size mismatch")
+	}
+
+	dstSize, delta, err := readDeltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, min(dstSize, uint64(len(base)+len(delta))))
+
+	for len(delta) > 0 {
+		cmd := delta[0]
+		delta = delta[1:]
+
+		switch {
+		case cmd&0x80 != 0:
+			var offset, size uint64
+
+			for i := uint(0); i < 4; i++ {
+				if cmd&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errors.New("truncated delta")
+					}
+
+					offset |= uint64(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+
+			for i := uint(0); i < 3; i++ {
+				if cmd&(1<<(4+i)) != 0 {
+					if len(delta) == 0 {
+						return nil, errors.New("truncated delta")
+					}
+
+					size |= uint64(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+
+			if size == 0 {
+				size = 0x10000
+			}
+
+			if offset+size > uint64(len(base)) {
+				return nil, errors.New("delta copy out of range")
+			}
+
+			result = append(result, base[offset:offset+size]...)
+		case cmd != 0:
+			if int(cmd) > len(delta) {
+				return nil, errors.New("truncated delta")
+			}
+
+			result = append(result, delta[:cmd]...)
+			delta = delta[cmd:]
+		default:
+			return nil, errors.New("invalid delta instruction")
+		}
+	}
+
+	if uint64(len(result)) != dstSize {
+		return nil, errors.New("delta result size mismatch")
+	}
+
+	return result, nil
+}
+
+// readDeltaSize reads a little-endian base-128 size from the start of a
+// delta, returning the size and the remaining bytes.
+func readDeltaSize(delta []byte) (uint64, []byte, error) {
+	var (
+		size  uint64
+		shift uint
+	)
+
+	for i, b := range delta {
+		size |= uint64(b&0x7f) << shift
+		shift += 7
+
+		if b&0x80 == 0 {
+			return size, delta[i+1:], nil
+		}
+
+		if shift > 63 {
+			break
+		}
+	}
+
+	return 0, nil, errors.New("invalid delta size")
+}
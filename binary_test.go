@@ -0,0 +1,112 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitDiff_BinaryFilesDiffer(t *testing.T) {
+	diff := `diff --git a/image.png b/image.png
+index 1111111..2222222 100644
+Binary files a/image.png and b/image.png differ`
+
+	result := ParseGitDiff(diff, nil)
+
+	require.Len(t, result, 1)
+	require.True(t, result[0].IsBinary)
+	require.Nil(t, result[0].BinaryPatch)
+	require.Empty(t, result[0].Hunks)
+}
+
+func TestParseGitDiff_BinaryLiteral(t *testing.T) {
+	diff := `diff --git a/x.bin b/x.bin
+new file mode 100644
+index 0000000000000000000000000000000000000000..db12d84d7d09898766cc3d68c37aa7d58f6c3702
+GIT binary patch
+literal 11
+Scmc~u&B@7UD9<m-NdW*EO9VXt
+
+literal 0
+HcmV?d00001`
+
+	result := ParseGitDiff(diff, nil)
+
+	require.Len(t, result, 1)
+	require.True(t, result[0].IsBinary)
+	require.Equal(t, StatusAdded, result[0].Status)
+
+	patch := result[0].BinaryPatch
+	require.NotNil(t, patch)
+	require.Equal(t, BinaryLiteral, patch.Forward.Method)
+	require.Equal(t, int64(11), patch.Forward.Size)
+
+	blob, err := patch.Forward.Apply(nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello\x00world"), blob)
+
+	require.NotNil(t, patch.Reverse)
+	require.Equal(t, int64(0), patch.Reverse.Size)
+	require.Empty(t, patch.Reverse.Data)
+}
+
+func TestParseGitDiff_BinaryDelta(t *testing.T) {
+	diff := `diff --git a/d.bin b/d.bin
+index 51e14664fcab480f7772d3f30bbecf877bcb0878..18b8f5ea06e452ba1b09d415c6581772b008e8a5 100644
+GIT binary patch
+delta 12
+TcmZ3(w1#Oy3KIk4#I$1o8I1%H
+
+delta 12
+UcmZ3(w1#Oy3e&N(6Vr|X03e$MqW}N^`
+
+	oldBlob := make([]byte, 300)
+	for i := range oldBlob {
+		oldBlob[i] = byte((i * 7) % 251)
+	}
+
+	newBlob := append([]byte{}, oldBlob...)
+	newBlob[100], newBlob[101] = 0, 1
+
+	result := ParseGitDiff(diff, nil)
+
+	require.Len(t, result, 1)
+	require.True(t, result[0].IsBinary)
+
+	patch := result[0].BinaryPatch
+	require.Equal(t, BinaryDelta, patch.Forward.Method)
+
+	got, err := patch.Forward.Apply(oldBlob)
+	require.NoError(t, err)
+	require.Equal(t, newBlob, got)
+
+	got, err = patch.Reverse.Apply(newBlob)
+	require.NoError(t, err)
+	require.Equal(t, oldBlob, got)
+
+	_, err = patch.Forward.Apply(oldBlob[:10])
+	require.Error(t, err)
+}
+
+func TestParseGitDiffFileString_InvalidBinaryPatch(t *testing.T) {
+	input := `diff --git a/x.bin b/x.bin
+index 1111111..2222222 100644
+GIT binary patch
+literal 11
+Scmc~u&B@7UD9<m-NdW*EO9VX`
+
+	got, err := parseGitDiffFileString(input, false, ParseOptions{})
+
+	require.Error(t, err)
+	require.Nil(t, got)
+}
+
+func TestDecodeBase85Line(t *testing.T) {
+	decoded, err := decodeBase85Line("HcmV?d00001")
+
+	require.NoError(t, err)
+	require.Equal(t, []byte{0x78, 0x01, 0x03, 0x00, 0x00, 0x00, 0x00, 0x01}, decoded)
+
+	_, err = decodeBase85Line("H\"mV?d00001")
+	require.Error(t, err)
+}
@@ -0,0 +1,513 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// bitbucketCloudAPIURL is the base URL of the Bitbucket Cloud API.
+const bitbucketCloudAPIURL = "https://api.bitbucket.org/2.0/"
+
+// bitbucketNameRegex matches Bitbucket workspace IDs, project keys and
+// repository slugs. The "~" prefix marks the personal project of a
+// Bitbucket Server user.
+var bitbucketNameRegex = regexp.MustCompile(`^~?[A-Za-z0-9_.-]+$`)
+
+// BitbucketPullRequestURL identifies a pull request on Bitbucket Cloud or on Bitbucket Server
+// and Data Center.
+type BitbucketPullRequestURL struct {
+	// Host is the host name of the Bitbucket instance, such as
+	// "bitbucket.org" for Bitbucket Cloud.
+	Host string
+
+	// Server is true for Bitbucket Server and Data Center, and false for
+	// Bitbucket Cloud.
+	Server bool
+
+	// ContextPath is the path a Bitbucket Server instance is served under,
+	// such as "/bitbucket", or empty if it is served at the root.
+	ContextPath string
+
+	// Owner is the workspace ID on Bitbucket Cloud, or the project key on
+	// Bitbucket Server. The personal project of a Bitbucket Server user is
+	// written as "~" followed by the user name.
+	Owner string
+
+	// Repo is the repository slug.
+	Repo string
+
+	// ID is the number of the pull request.
+	ID int
+}
+
+// ParseBitbucketPullRequestURL parses the URL of a Bitbucket pull request. Bitbucket Cloud URLs
+// have the form https://bitbucket.org/[workspace]/[repo]/pull-requests/[id], and Bitbucket
+// Server URLs the form https://[host]/projects/[project]/repos/[repo]/pull-requests/[id] or,
+// for personal repositories, https://[host]/users/[user]/repos/[repo]/pull-requests/[id].
+// Sub-pages such as /diff or /overview, a Bitbucket Server context path, and the matching
+// REST API URLs of both products are accepted as well.
+//
+// Parameters:
+//   - pullRequestURL: A string representing the URL of a Bitbucket pull request.
+//
+// Returns:
+//   - A pointer to a BitbucketPullRequestURL struct containing the extracted information.
+//   - An error matching ErrInvalidURL if the URL is not a valid Bitbucket pull request URL.
+//
+// Example:
+//
+//	pr, err := ParseBitbucketPullRequestURL("https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/42")
+//	if err != nil {
+//	  // Handle error
+//	}
+//	source := &BitbucketPullRequestSource{Fetcher: fetcher, PullRequest: pr}
+func ParseBitbucketPullRequestURL(pullRequestURL string) (*BitbucketPullRequestURL, error) {
+	pr, ok := parseBitbucketPullRequestReference(pullRequestURL)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a Bitbucket pull request URL", ErrInvalidURL, pullRequestURL)
+	}
+
+	return pr, nil
+}
+
+// parseBitbucketPullRequestReference parses the forms accepted by
+// ParseBitbucketPullRequestURL.
+func parseBitbucketPullRequestReference(reference string) (*BitbucketPullRequestURL, bool) {
+	host, segments, err := splitGitHubURL(reference)
+	if err != nil || host == "github.com" {
+		return nil, false
+	}
+
+	var pr *BitbucketPullRequestURL
+
+	switch host {
+	case "bitbucket.org", "www.bitbucket.org":
+		if len(segments) < 4 || segments[2] != "pull-requests" {
+			return nil, false
+		}
+
+		pr = &BitbucketPullRequestURL{Owner: segments[0], Repo: segments[1]}
+		segments = segments[3:]
+	case "api.bitbucket.org":
+		if len(segments) < 6 || segments[0] != "2.0" || segments[1] != "repositories" || segments[4] != "pullrequests" {
+			return nil, false
+		}
+
+		pr = &BitbucketPullRequestURL{Owner: segments[2], Repo: segments[3]}
+		segments = segments[5:]
+	default:
+		pr, segments = parseBitbucketServerSegments(segments)
+		if pr == nil {
+			return nil, false
+		}
+	}
+
+	if pr.Server {
+		pr.Host = host
+	} else {
+		pr.Host = "bitbucket.org"
+	}
+
+	if !bitbucketNameRegex.MatchString(pr.Owner) || !bitbucketNameRegex.MatchString(pr.Repo) {
+		return nil, false
+	}
+
+	id, ok := parsePositiveInt(strings.TrimSuffix(strings.TrimSuffix(segments[0], ".diff"), ".patch"))
+	if !ok {
+		return nil, false
+	}
+
+	pr.ID = id
+	pr.Repo = strings.TrimSuffix(pr.Repo, ".git")
+
+	return pr, true
+}
+
+// parseBitbucketServerSegments finds the projects/[project]/repos/[repo]/pull-requests
+// or users/[user]/repos/[repo]/pull-requests part of a Bitbucket Server
+// path, and returns the pull request along with the segments that follow.
+// The segments that precede it are the context path, without the
+// rest/api/[version] prefix of REST API URLs.
+func parseBitbucketServerSegments(segments []string) (*BitbucketPullRequestURL, []string) {
+	for i := 0; i+5 < len(segments); i++ {
+		if (segments[i] != "projects" && segments[i] != "users") || segments[i+2] != "repos" || segments[i+4] != "pull-requests" {
+			continue
+		}
+
+		contextPath := segments[:i]
+		if n := len(contextPath); n >= 3 && contextPath[n-3] == "rest" && contextPath[n-2] == "api" {
+			contextPath = contextPath[:n-3]
+		}
+
+		pr := &BitbucketPullRequestURL{
+			Server: true,
+			Owner:  segments[i+1],
+			Repo:   segments[i+3],
+		}
+
+		if segments[i] == "users" {
+			pr.Owner = "~" + pr.Owner
+		}
+
+		if len(contextPath) > 0 {
+			pr.ContextPath = "/" + strings.Join(contextPath, "/")
+		}
+
+		return pr, segments[i+5:]
+	}
+
+	return nil, nil
+}
+
+// BitbucketPullRequestSource is the DiffSource of a Bitbucket Cloud or Bitbucket Server pull
+// request. The diff is retrieved from the raw diff endpoint of the REST API with the HTTP
+// client, user agent, retry policy and size limit of the Fetcher, along with the token set by
+// WithBitbucketToken and the base URL set by WithBitbucketBaseURL. The token is sent as a
+// bearer token, which works for access tokens on Bitbucket Cloud and personal access tokens
+// on Bitbucket Server; use WithHTTPClient with an authenticating transport for other
+// schemes, such as app passwords. The base URL defaults to https://api.bitbucket.org/2.0/
+// for Bitbucket Cloud and to https://[host][context path]/rest/api/latest/ for Bitbucket
+// Server. A client set with WithGitHubClient is not used. To use a Bitbucket pull request
+// with the functions that accept a GitHubClientInterface, use BitbucketClient instead.
+type BitbucketPullRequestSource struct {
+	// Fetcher holds the settings used to retrieve the diff. A nil Fetcher
+	// behaves like one created by NewFetcher without options.
+	Fetcher *Fetcher
+
+	// PullRequest identifies the pull request.
+	PullRequest *BitbucketPullRequestURL
+}
+
+// GitDiffs retrieves the diff of the pull request with Fetcher.BitbucketGitDiffs.
+func (s *BitbucketPullRequestSource) GitDiffs(ctx context.Context, opts ParseOptions) ([]*GitDiff, error) {
+	return fetcherOrDefault(s.Fetcher).BitbucketGitDiffs(ctx, s.PullRequest, opts)
+}
+
+// BitbucketDiff retrieves the raw Git diff of a Bitbucket Cloud or Bitbucket Server pull
+// request.
+func (f *Fetcher) BitbucketDiff(ctx context.Context, pr *BitbucketPullRequestURL) (string, error) {
+	body, _, err := f.openBitbucketRaw(ctx, pr, github.Diff)
+	if err != nil {
+		return "", err
+	}
+
+	return readDiffBody(body)
+}
+
+// BitbucketGitDiffs retrieves the diff of a Bitbucket pull request with BitbucketDiff and
+// parses it with ParseGitDiffE.
+//
+// Example:
+//
+//	pr := &BitbucketPullRequestURL{Host: "bitbucket.org", Owner: "workspace", Repo: "repo", ID: 42}
+//	gitDiffs, err := fetcher.BitbucketGitDiffs(ctx, pr, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+func (f *Fetcher) BitbucketGitDiffs(ctx context.Context, pr *BitbucketPullRequestURL, opts ParseOptions) ([]*GitDiff, error) {
+	if _, err := compileIgnoreList(opts.IgnoreList); err != nil {
+		return nil, err
+	}
+
+	diff, err := f.BitbucketDiff(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGitDiffE(diff, opts)
+}
+
+// bitbucketBaseURL returns the base URL of the Bitbucket API for a pull
+// request, with a trailing slash.
+func (f *Fetcher) bitbucketBaseURL(pr *BitbucketPullRequestURL) string {
+	switch {
+	case f.bitbucketAPIURL != nil:
+		return f.bitbucketAPIURL.String()
+	case pr.Server:
+		return "https://" + pr.Host + pr.ContextPath + "/rest/api/latest/"
+	default:
+		return bitbucketCloudAPIURL
+	}
+}
+
+// bitbucketPullRequestAPIURL returns the REST API URL of a pull request,
+// without a trailing slash.
+func (f *Fetcher) bitbucketPullRequestAPIURL(pr *BitbucketPullRequestURL) string {
+	owner, repo := url.PathEscape(pr.Owner), url.PathEscape(pr.Repo)
+
+	if pr.Server {
+		return fmt.Sprintf("%sprojects/%s/repos/%s/pull-requests/%d", f.bitbucketBaseURL(pr), owner, repo, pr.ID)
+	}
+
+	return fmt.Sprintf("%srepositories/%s/%s/pullrequests/%d", f.bitbucketBaseURL(pr), owner, repo, pr.ID)
+}
+
+// openBitbucket makes a GET request to the Bitbucket API with the token set
+// by WithBitbucketToken.
+func (f *Fetcher) openBitbucket(ctx context.Context, rawURL, accept string) (io.ReadCloser, *http.Response, error) {
+	var header http.Header
+	if f.bitbucketToken != "" {
+		header = http.Header{"Authorization": {"Bearer " + f.bitbucketToken}}
+	}
+
+	return f.openProviderURL(ctx, rawURL, accept, header)
+}
+
+// openBitbucketRaw opens the raw diff or patch of a pull request. Bitbucket
+// Cloud serves them under /diff and /patch, and Bitbucket Server with a
+// .diff or .patch suffix.
+func (f *Fetcher) openBitbucketRaw(
+	ctx context.Context,
+	pr *BitbucketPullRequestURL,
+	rawType github.RawType,
+) (io.ReadCloser, *http.Response, error) {
+	var suffix string
+
+	switch rawType {
+	case github.Diff:
+		suffix = "diff"
+	case github.Patch:
+		suffix = "patch"
+	default:
+		return nil, nil, errors.New("unsupported raw type")
+	}
+
+	if pr.Server {
+		return f.openBitbucket(ctx, f.bitbucketPullRequestAPIURL(pr)+"."+suffix, "text/plain")
+	}
+
+	return f.openBitbucket(ctx, f.bitbucketPullRequestAPIURL(pr)+"/"+suffix, "text/plain")
+}
+
+// BitbucketClient adapts a Bitbucket Cloud or Bitbucket Server instance to
+// GitHubClientInterface, so that it can be passed wherever a GitHub client is accepted, such
+// as GetPullRequestWithClient, GetPullRequestDiffReader, GetPullRequestGitDiffs and
+// WithGitHubClient. The Owner and Repo of a PullRequestURL are used as the workspace ID and
+// repository slug on Bitbucket Cloud, or as the project key and repository slug on
+// Bitbucket Server, and its Host is ignored.
+//
+// Only pull requests and their raw diff and patch are available: the client does not
+// implement GitHubFilesClientInterface, GitHubCompareClientInterface or
+// GitHubCommitClientInterface, so the entry points that need them, such as
+// GetCommitGitDiffs or the file list fallback of GetPullRequestGitDiffs, return an error or
+// behave as described for clients that lack them.
+type BitbucketClient struct {
+	// Fetcher holds the settings used to call the Bitbucket API, as
+	// described for BitbucketPullRequestSource. A nil Fetcher behaves like
+	// one created by NewFetcher without options.
+	Fetcher *Fetcher
+
+	// Host, Server and ContextPath identify the Bitbucket instance, as in
+	// BitbucketPullRequestURL. An empty Host is treated as "bitbucket.org".
+	Host        string
+	Server      bool
+	ContextPath string
+}
+
+// NewBitbucketClientForPullRequest creates a BitbucketClient for the Bitbucket instance of
+// a pull request, and returns it along with the PullRequestURL to pass to the functions
+// that accept a GitHubClientInterface.
+//
+// Example:
+//
+//	pr, err := ParseBitbucketPullRequestURL("https://bitbucket.org/workspace/repo/pull-requests/42")
+//	if err != nil {
+//	  // Handle error
+//	}
+//	client, prURL := NewBitbucketClientForPullRequest(pr, fetcher)
+//	gitDiffs, err := GetPullRequestGitDiffs(ctx, prURL, client, ParseOptions{IgnoreList: ignoreList})
+func NewBitbucketClientForPullRequest(pr *BitbucketPullRequestURL, fetcher *Fetcher) (*BitbucketClient, *PullRequestURL) {
+	client := &BitbucketClient{
+		Fetcher:     fetcher,
+		Host:        pr.Host,
+		Server:      pr.Server,
+		ContextPath: pr.ContextPath,
+	}
+
+	return client, &PullRequestURL{Host: pr.Host, Owner: pr.Owner, Repo: pr.Repo, PRNumber: pr.ID}
+}
+
+// pullRequest returns the Bitbucket pull request with the given owner,
+// repository and number on the client's instance.
+func (c *BitbucketClient) pullRequest(owner, repo string, number int) *BitbucketPullRequestURL {
+	host := c.Host
+	if host == "" {
+		host = "bitbucket.org"
+	}
+
+	return &BitbucketPullRequestURL{
+		Host:        host,
+		Server:      c.Server,
+		ContextPath: c.ContextPath,
+		Owner:       owner,
+		Repo:        repo,
+		ID:          number,
+	}
+}
+
+// Get retrieves a pull request from the Bitbucket API and returns the fields that have a
+// GitHub equivalent: its number, title, description, state, web URL and the branches and
+// commits of its source and destination. The state is "open", or "closed" with Merged set
+// for merged pull requests.
+func (c *BitbucketClient) Get(
+	ctx context.Context,
+	owner string,
+	repo string,
+	number int,
+) (*github.PullRequest, *github.Response, error) {
+	fetcher := fetcherOrDefault(c.Fetcher)
+	pr := c.pullRequest(owner, repo, number)
+
+	body, resp, err := fetcher.openBitbucket(ctx, fetcher.bitbucketPullRequestAPIURL(pr), "application/json")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeBody(body)
+
+	var pullRequest *github.PullRequest
+	if pr.Server {
+		pullRequest, err = decodeBitbucketServerPullRequest(body)
+	} else {
+		pullRequest, err = decodeBitbucketCloudPullRequest(body)
+	}
+
+	if err != nil {
+		return nil, &github.Response{Response: resp}, err
+	}
+
+	return pullRequest, &github.Response{Response: resp}, nil
+}
+
+// GetRaw retrieves the raw diff or patch of a pull request from the Bitbucket API, as
+// selected by opts.Type. The caller must close the returned io.ReadCloser.
+func (c *BitbucketClient) GetRaw(
+	ctx context.Context,
+	owner string,
+	repo string,
+	number int,
+	opts github.RawOptions,
+) (io.ReadCloser, *github.Response, error) {
+	body, resp, err := fetcherOrDefault(c.Fetcher).openBitbucketRaw(ctx, c.pullRequest(owner, repo, number), opts.Type)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, &github.Response{Response: resp}, nil
+}
+
+// bitbucketRef is the source or destination of a Bitbucket Cloud pull
+// request.
+type bitbucketRef struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+	Commit struct {
+		Hash string `json:"hash"`
+	} `json:"commit"`
+}
+
+// decodeBitbucketCloudPullRequest converts a pull request returned by the
+// Bitbucket Cloud API into a github.PullRequest.
+func decodeBitbucketCloudPullRequest(body io.Reader) (*github.PullRequest, error) {
+	var pr struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		State       string `json:"state"`
+		Links       struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+		Source      bitbucketRef `json:"source"`
+		Destination bitbucketRef `json:"destination"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&pr); err != nil {
+		return nil, err
+	}
+
+	return newBitbucketGitHubPullRequest(
+		pr.ID, pr.Title, pr.Description, pr.State, pr.Links.HTML.Href,
+		pr.Source.Branch.Name, pr.Source.Commit.Hash,
+		pr.Destination.Branch.Name, pr.Destination.Commit.Hash,
+	), nil
+}
+
+// bitbucketServerRef is the source or destination of a Bitbucket Server
+// pull request.
+type bitbucketServerRef struct {
+	DisplayID    string `json:"displayId"`
+	LatestCommit string `json:"latestCommit"`
+}
+
+// decodeBitbucketServerPullRequest converts a pull request returned by the
+// Bitbucket Server API into a github.PullRequest.
+func decodeBitbucketServerPullRequest(body io.Reader) (*github.PullRequest, error) {
+	var pr struct {
+		ID          int    `json:"id"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		State       string `json:"state"`
+		Links       struct {
+			Self []struct {
+				Href string `json:"href"`
+			} `json:"self"`
+		} `json:"links"`
+		FromRef bitbucketServerRef `json:"fromRef"`
+		ToRef   bitbucketServerRef `json:"toRef"`
+	}
+
+	if err := json.NewDecoder(body).Decode(&pr); err != nil {
+		return nil, err
+	}
+
+	var htmlURL string
+	if len(pr.Links.Self) > 0 {
+		htmlURL = pr.Links.Self[0].Href
+	}
+
+	return newBitbucketGitHubPullRequest(
+		pr.ID, pr.Title, pr.Description, pr.State, htmlURL,
+		pr.FromRef.DisplayID, pr.FromRef.LatestCommit,
+		pr.ToRef.DisplayID, pr.ToRef.LatestCommit,
+	), nil
+}
+
+// newBitbucketGitHubPullRequest builds a github.PullRequest from the fields
+// of a Bitbucket pull request, whose state is OPEN, MERGED, DECLINED or
+// SUPERSEDED.
+func newBitbucketGitHubPullRequest(
+	id int,
+	title, description, state, htmlURL string,
+	headRef, headSHA, baseRef, baseSHA string,
+) *github.PullRequest {
+	pullRequest := &github.PullRequest{
+		Number: github.Int(id),
+		Title:  github.String(title),
+		Body:   github.String(description),
+		State:  github.String("closed"),
+		Merged: github.Bool(state == "MERGED"),
+		Head:   &github.PullRequestBranch{Ref: github.String(headRef), SHA: github.String(headSHA)},
+		Base:   &github.PullRequestBranch{Ref: github.String(baseRef), SHA: github.String(baseSHA)},
+	}
+
+	if state == "OPEN" {
+		pullRequest.State = github.String("open")
+	}
+
+	if htmlURL != "" {
+		pullRequest.HTMLURL = github.String(htmlURL)
+	}
+
+	return pullRequest
+}
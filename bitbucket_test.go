@@ -0,0 +1,253 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const bitbucketTestDiff = "diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n" +
+	"diff --git a/go.sum b/go.sum\nindex 3..4 100644\n--- a/go.sum\n+++ b/go.sum\n@@ -1 +1 @@\n-c\n+d\n"
+
+func TestParseBitbucketPullRequestURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *BitbucketPullRequestURL
+	}{
+		{
+			name:  "Cloud",
+			input: "https://bitbucket.org/workspace/repo/pull-requests/42",
+			want:  &BitbucketPullRequestURL{Host: "bitbucket.org", Owner: "workspace", Repo: "repo", ID: 42},
+		},
+		{
+			name:  "Cloud sub-page without scheme",
+			input: "bitbucket.org/workspace/repo/pull-requests/42/diff#chg-main.go",
+			want:  &BitbucketPullRequestURL{Host: "bitbucket.org", Owner: "workspace", Repo: "repo", ID: 42},
+		},
+		{
+			name:  "Cloud API",
+			input: "https://api.bitbucket.org/2.0/repositories/workspace/repo/pullrequests/42/diff",
+			want:  &BitbucketPullRequestURL{Host: "bitbucket.org", Owner: "workspace", Repo: "repo", ID: 42},
+		},
+		{
+			name:  "Server project",
+			input: "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/7/overview",
+			want:  &BitbucketPullRequestURL{Host: "bitbucket.example.com", Server: true, Owner: "PROJ", Repo: "repo", ID: 7},
+		},
+		{
+			name:  "Server user",
+			input: "https://bitbucket.example.com/users/alice/repos/repo/pull-requests/7",
+			want:  &BitbucketPullRequestURL{Host: "bitbucket.example.com", Server: true, Owner: "~alice", Repo: "repo", ID: 7},
+		},
+		{
+			name:  "Server context path",
+			input: "https://example.com:7990/bitbucket/projects/PROJ/repos/repo/pull-requests/7/diff",
+			want:  &BitbucketPullRequestURL{Host: "example.com:7990", Server: true, ContextPath: "/bitbucket", Owner: "PROJ", Repo: "repo", ID: 7},
+		},
+		{
+			name:  "Server API",
+			input: "https://example.com/bitbucket/rest/api/latest/projects/~alice/repos/repo/pull-requests/7.diff",
+			want:  &BitbucketPullRequestURL{Host: "example.com", Server: true, ContextPath: "/bitbucket", Owner: "~alice", Repo: "repo", ID: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBitbucketPullRequestURL(tt.input)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseBitbucketPullRequestURL_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"https://bitbucket.org/workspace/repo/pull-requests",
+		"https://bitbucket.org/workspace/repo/pull-requests/0",
+		"https://bitbucket.org/workspace/repo/issues/42",
+		"https://api.bitbucket.org/2.0/repositories/workspace/repo/pullrequests",
+		"https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests",
+		"https://bitbucket.example.com/projects/PROJ/repos/repo/browse",
+		"https://github.com/owner/repo/pull/42",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			got, err := ParseBitbucketPullRequestURL(input)
+
+			require.ErrorIs(t, err, ErrInvalidURL)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestBitbucketGitDiffs(t *testing.T) {
+	tests := []struct {
+		name string
+		pr   *BitbucketPullRequestURL
+		path string
+	}{
+		{
+			name: "Cloud",
+			pr:   &BitbucketPullRequestURL{Host: "bitbucket.org", Owner: "workspace", Repo: "repo", ID: 42},
+			path: "/repositories/workspace/repo/pullrequests/42/diff",
+		},
+		{
+			name: "Server",
+			pr:   &BitbucketPullRequestURL{Host: "bitbucket.example.com", Server: true, Owner: "~alice", Repo: "repo", ID: 7},
+			path: "/projects/~alice/repos/repo/pull-requests/7.diff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+					return
+				}
+
+				if r.URL.Path != tt.path {
+					http.Error(w, "Not Found", http.StatusNotFound)
+
+					return
+				}
+
+				_, _ = w.Write([]byte(bitbucketTestDiff))
+			}))
+			defer testServer.Close()
+
+			source := &BitbucketPullRequestSource{
+				Fetcher: mustNewFetcher(t,
+					WithBitbucketBaseURL(testServer.URL),
+					WithHTTPClient(testServer.Client()),
+					WithBitbucketToken("secret"),
+					WithToken("github-token"),
+					WithGitLabToken("gitlab-token"),
+				),
+				PullRequest: tt.pr,
+			}
+
+			gitDiffs, err := source.GitDiffs(context.Background(), ParseOptions{IgnoreList: []string{`\.sum$`}})
+			require.NoError(t, err)
+			require.Len(t, gitDiffs, 1)
+			require.Equal(t, "main.go", gitDiffs[0].FilePathNew)
+
+			source.Fetcher = mustNewFetcher(t, WithBitbucketBaseURL(testServer.URL), WithHTTPClient(testServer.Client()))
+			_, err = source.GitDiffs(context.Background(), ParseOptions{})
+			require.ErrorIs(t, err, ErrUnauthorized)
+
+			source.Fetcher = mustNewFetcher(t, WithBitbucketBaseURL(testServer.URL), WithHTTPClient(testServer.Client()), WithBitbucketToken("secret"), WithMaxBytes(10))
+			_, err = source.GitDiffs(context.Background(), ParseOptions{})
+			require.ErrorIs(t, err, ErrDiffTooLarge)
+		})
+	}
+}
+
+func TestBitbucketBaseURL(t *testing.T) {
+	fetcher := &Fetcher{}
+
+	require.Equal(t, "https://api.bitbucket.org/2.0/", fetcher.bitbucketBaseURL(&BitbucketPullRequestURL{Host: "bitbucket.org"}))
+	require.Equal(t, "https://example.com/bitbucket/rest/api/latest/",
+		fetcher.bitbucketBaseURL(&BitbucketPullRequestURL{Host: "example.com", Server: true, ContextPath: "/bitbucket"}))
+}
+
+func TestBitbucketClient(t *testing.T) {
+	tests := []struct {
+		name   string
+		pr     *BitbucketPullRequestURL
+		prPath string
+		diff   string
+		patch  string
+		body   string
+	}{
+		{
+			name:   "Cloud",
+			pr:     &BitbucketPullRequestURL{Host: "bitbucket.org", Owner: "workspace", Repo: "repo", ID: 42},
+			prPath: "/repositories/workspace/repo/pullrequests/42",
+			diff:   "/repositories/workspace/repo/pullrequests/42/diff",
+			patch:  "/repositories/workspace/repo/pullrequests/42/patch",
+			body: `{"id": 42, "title": "Fix", "state": "MERGED", "links": {"html": {"href": "https://bitbucket.org/workspace/repo/pull-requests/42"}},
+				"source": {"branch": {"name": "fix"}, "commit": {"hash": "abc"}}, "destination": {"branch": {"name": "main"}, "commit": {"hash": "def"}}}`,
+		},
+		{
+			name:   "Server",
+			pr:     &BitbucketPullRequestURL{Host: "bitbucket.example.com", Server: true, Owner: "PROJ", Repo: "repo", ID: 42},
+			prPath: "/projects/PROJ/repos/repo/pull-requests/42",
+			diff:   "/projects/PROJ/repos/repo/pull-requests/42.diff",
+			patch:  "/projects/PROJ/repos/repo/pull-requests/42.patch",
+			body: `{"id": 42, "title": "Fix", "state": "MERGED", "links": {"self": [{"href": "https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/42"}]},
+				"fromRef": {"displayId": "fix", "latestCommit": "abc"}, "toRef": {"displayId": "main", "latestCommit": "def"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer secret" {
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+					return
+				}
+
+				switch r.URL.Path {
+				case tt.prPath:
+					_, _ = w.Write([]byte(tt.body))
+				case tt.diff:
+					_, _ = w.Write([]byte(bitbucketTestDiff))
+				case tt.patch:
+					_, _ = w.Write([]byte("mock patch"))
+				default:
+					http.Error(w, "Not Found", http.StatusNotFound)
+				}
+			}))
+			defer testServer.Close()
+
+			client, prURL := NewBitbucketClientForPullRequest(tt.pr, mustNewFetcher(t,
+				WithBitbucketBaseURL(testServer.URL),
+				WithHTTPClient(testServer.Client()),
+				WithBitbucketToken("secret"),
+			))
+			require.Equal(t, &PullRequestURL{Host: tt.pr.Host, Owner: tt.pr.Owner, Repo: tt.pr.Repo, PRNumber: 42}, prURL)
+
+			diff, err := GetPullRequestWithClient(context.Background(), prURL, client)
+			require.NoError(t, err)
+			require.Equal(t, bitbucketTestDiff, diff)
+
+			gitDiffs, err := GetPullRequestGitDiffs(context.Background(), prURL, client, ParseOptions{IgnoreList: []string{`\.sum$`}})
+			require.NoError(t, err)
+			require.Len(t, gitDiffs, 1)
+			require.Equal(t, "main.go", gitDiffs[0].FilePathNew)
+
+			fetcher := mustNewFetcher(t, WithGitHubClient(client))
+
+			patch, err := fetcher.Patch(context.Background(), prURL)
+			require.NoError(t, err)
+			require.Equal(t, "mock patch", patch)
+
+			pullRequest, err := fetcher.PullRequest(context.Background(), prURL)
+			require.NoError(t, err)
+			require.Equal(t, 42, pullRequest.GetNumber())
+			require.Equal(t, "Fix", pullRequest.GetTitle())
+			require.Equal(t, "closed", pullRequest.GetState())
+			require.True(t, pullRequest.GetMerged())
+			require.Contains(t, pullRequest.GetHTMLURL(), "/pull-requests/42")
+			require.Equal(t, "abc", pullRequest.GetHead().GetSHA())
+			require.Equal(t, "main", pullRequest.GetBase().GetRef())
+
+			_, err = fetcher.Diff(context.Background(), &PullRequestURL{Owner: tt.pr.Owner, Repo: tt.pr.Repo, PRNumber: 43})
+			require.ErrorIs(t, err, ErrNotFound)
+
+			client.Fetcher = mustNewFetcher(t, WithBitbucketBaseURL(testServer.URL), WithHTTPClient(testServer.Client()))
+			_, err = GetPullRequestWithClient(context.Background(), prURL, client)
+			require.ErrorIs(t, err, ErrUnauthorized)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// commitSHARegex matches full and abbreviated commit hashes.
+var commitSHARegex = regexp.MustCompile(`^[0-9a-fA-F]{7,64}$`)
+
+// CommitURL identifies a single commit of a GitHub repository.
+type CommitURL struct {
+	// Host is the host name of the GitHub instance, such as "github.com".
+	// An empty Host is treated as "github.com".
+	Host  string
+	Owner string
+	Repo  string
+
+	// SHA is the full or abbreviated hash of the commit.
+	SHA string
+
+	// PRNumber is the number of the pull request the commit was viewed in,
+	// for URLs of the form /pull/[number]/commits/[sha]. It is 0 otherwise.
+	PRNumber int
+}
+
+// ParseCommitURL parses a GitHub commit URL and returns the host, owner, repository and
+// commit hash. Both https://github.com/[owner]/[repo]/commit/[sha] and commits viewed in a
+// pull request, https://github.com/[owner]/[repo]/pull/[prNumber]/commits/[sha], are
+// accepted, as are .diff and .patch suffixes and API URLs such as
+// https://api.github.com/repos/[owner]/[repo]/commits/[sha].
+//
+// Parameters:
+//   - commitURL: A string representing the URL of a GitHub commit.
+//
+// Returns:
+//   - A pointer to a CommitURL struct containing the extracted information.
+//   - An error matching ErrInvalidURL if the URL is not a valid commit URL.
+//
+// Example:
+//
+//	commit, err := ParseCommitURL("https://github.com/username/repository/commit/0123abcd")
+//	if err != nil {
+//	  // Handle error
+//	}
+//	gitDiffs, err := GetCommitGitDiffs(ctx, commit, ghClient, ParseOptions{})
+func ParseCommitURL(commitURL string) (*CommitURL, error) {
+	commit, ok := parseCommitReference(commitURL)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a commit URL", ErrInvalidURL, commitURL)
+	}
+
+	return commit, nil
+}
+
+// parseCommitReference parses the forms accepted by ParseCommitURL.
+func parseCommitReference(reference string) (*CommitURL, bool) {
+	host, segments, err := splitGitHubURL(reference)
+	if err != nil {
+		return nil, false
+	}
+
+	owner, repo, ok := parseRepoSegments(segments)
+	if !ok {
+		return nil, false
+	}
+
+	commit := &CommitURL{Host: host, Owner: owner, Repo: repo}
+
+	var sha string
+
+	switch {
+	case len(segments) == 4 && (segments[2] == "commit" || segments[2] == "commits"):
+		sha = segments[3]
+	case len(segments) == 6 && (segments[2] == "pull" || segments[2] == "pulls") && segments[4] == "commits":
+		commit.PRNumber, ok = parsePositiveInt(segments[3])
+		if !ok {
+			return nil, false
+		}
+
+		sha = segments[5]
+	default:
+		return nil, false
+	}
+
+	sha = strings.TrimSuffix(strings.TrimSuffix(sha, ".diff"), ".patch")
+	if !commitSHARegex.MatchString(sha) {
+		return nil, false
+	}
+
+	commit.SHA = sha
+
+	return commit, true
+}
+
+// GitHubCommitClientInterface extends GitHubClientInterface with the ability to download
+// the raw diff or patch of a single commit, as used by GetCommitGitDiffs.
+type GitHubCommitClientInterface interface {
+	GitHubClientInterface
+
+	// CommitRaw retrieves the raw diff or patch of a commit, as selected by opts.Type.
+	// The caller must close the returned io.ReadCloser.
+	CommitRaw(
+		ctx context.Context,
+		owner string,
+		repo string,
+		sha string,
+		opts github.RawOptions,
+	) (io.ReadCloser, *github.Response, error)
+}
+
+// CommitRaw streams the raw diff or patch of a commit from the commits API, using the same
+// authenticated transport as every other call made with the wrapped client. The caller must
+// close the returned io.ReadCloser.
+func (c *GitHubClientWrapper) CommitRaw(
+	ctx context.Context,
+	owner string,
+	repo string,
+	sha string,
+	opts github.RawOptions,
+) (io.ReadCloser, *github.Response, error) {
+	return c.getRaw(ctx, fmt.Sprintf("repos/%v/%v/commits/%v", owner, repo, escapeRef(sha)), opts)
+}
+
+// CommitRaw calls the mock implementation of the CommitRaw method. If MockCommitRaw is set
+// to a custom function, that function is executed and its result returned. If MockCommitRaw
+// is not set, the method returns an error.
+func (m *MockGitClient) CommitRaw(
+	ctx context.Context,
+	owner string,
+	repo string,
+	sha string,
+	opts github.RawOptions,
+) (io.ReadCloser, *github.Response, error) {
+	if m.MockCommitRaw != nil {
+		return m.MockCommitRaw(ctx, owner, repo, sha, opts)
+	}
+
+	return nil, nil, errMockNotSet("MockCommitRaw")
+}
+
+// GetCommitGitDiffs retrieves the diff of a single commit and parses it into one GitDiff
+// per file, applying the same options as ParseGitDiffE. It is equivalent to calling
+// Fetcher.CommitGitDiffs on a Fetcher created with WithGitHubClient(client).
+//
+// Parameters:
+//   - ctx: A context.Context object, used for managing the lifecycle of the request.
+//   - commit: A pointer to a CommitURL struct, containing the owner, repository and commit hash.
+//   - client: An implementation of the GitHubClientInterface that also implements
+//     GitHubCommitClientInterface, such as GitHubClientWrapper.
+//   - opts: A ParseOptions struct containing the ignore list and other parsing settings.
+//
+// Returns:
+//   - A slice of GitDiff structs, each representing a parsed and non-ignored file diff.
+//   - An error if the client cannot download commits or the diff cannot be retrieved, or a
+//     *ParseError if some file diffs could not be parsed.
+//
+// Example:
+//
+//	commit := &CommitURL{Owner: "username", Repo: "repository", SHA: "0123abcd"}
+//	gitDiffs, err := GetCommitGitDiffs(ctx, commit, ghClient, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+func GetCommitGitDiffs(
+	ctx context.Context,
+	commit *CommitURL,
+	client GitHubClientInterface,
+	opts ParseOptions,
+) ([]*GitDiff, error) {
+	fetcher, err := NewFetcher(WithGitHubClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.CommitGitDiffs(ctx, commit, opts)
+}
+
+// CommitDiff retrieves the raw Git diff of a single commit.
+func (f *Fetcher) CommitDiff(ctx context.Context, commit *CommitURL) (string, error) {
+	client, err := f.gitHubClient(commit.Host)
+	if err != nil {
+		return "", err
+	}
+
+	commitClient, ok := client.(GitHubCommitClientInterface)
+	if !ok {
+		return "", errors.New("client does not implement GitHubCommitClientInterface")
+	}
+
+	body, resp, err := commitClient.CommitRaw(ctx, commit.Owner, commit.Repo, commit.SHA, github.RawOptions{Type: github.Diff})
+
+	return f.readRawResponse(body, resp, err)
+}
+
+// CommitGitDiffs retrieves the diff of a single commit with CommitDiff and parses it with
+// ParseGitDiffE.
+func (f *Fetcher) CommitGitDiffs(ctx context.Context, commit *CommitURL, opts ParseOptions) ([]*GitDiff, error) {
+	diff, err := f.CommitDiff(ctx, commit)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGitDiffE(diff, opts)
+}
@@ -0,0 +1,123 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommitURL(t *testing.T) {
+	sha := "0123456789abcdef0123456789abcdef01234567"
+
+	tests := []struct {
+		name  string
+		input string
+		want  *CommitURL
+	}{
+		{
+			name:  "Commit",
+			input: "https://github.com/owner/repo/commit/" + sha,
+			want:  &CommitURL{Host: "github.com", Owner: "owner", Repo: "repo", SHA: sha},
+		},
+		{
+			name:  "Abbreviated with diff suffix",
+			input: "https://github.com/owner/repo/commit/0123abcd.diff",
+			want:  &CommitURL{Host: "github.com", Owner: "owner", Repo: "repo", SHA: "0123abcd"},
+		},
+		{
+			name:  "Pull request commit",
+			input: "https://github.com/owner/repo/pull/12/commits/" + sha + "#diff-123",
+			want:  &CommitURL{Host: "github.com", Owner: "owner", Repo: "repo", SHA: sha, PRNumber: 12},
+		},
+		{
+			name:  "API URL",
+			input: "https://api.github.com/repos/owner/repo/commits/" + sha,
+			want:  &CommitURL{Host: "github.com", Owner: "owner", Repo: "repo", SHA: sha},
+		},
+		{
+			name:  "Enterprise",
+			input: "ghe.example.com/owner/repo/commit/" + sha,
+			want:  &CommitURL{Host: "ghe.example.com", Owner: "owner", Repo: "repo", SHA: sha},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCommitURL(tt.input)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCommitURL_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"https://github.com/owner/repo/commit",
+		"https://github.com/owner/repo/commit/main",
+		"https://github.com/owner/repo/commit/abc",
+		"https://github.com/owner/repo/pull/12/commits",
+		"https://github.com/owner/repo/pull/0/commits/0123abcd",
+		"https://github.com/owner/repo/pull/12",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			got, err := ParseCommitURL(input)
+
+			require.ErrorIs(t, err, ErrInvalidURL)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestGetCommitGitDiffs(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/commits/0123abcd" || r.Header.Get("Accept") != "application/vnd.github.v3.diff" {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n" +
+			"diff --git a/go.sum b/go.sum\nindex 3..4 100644\n--- a/go.sum\n+++ b/go.sum\n@@ -1 +1 @@\n-c\n+d\n"))
+	}))
+	defer testServer.Close()
+
+	commit := &CommitURL{Owner: "owner", Repo: "repo", SHA: "0123abcd"}
+
+	gitDiffs, err := GetCommitGitDiffs(context.Background(), commit, newTestGitHubClient(t, testServer, ""), ParseOptions{IgnoreList: []string{`\.sum$`}})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "main.go", gitDiffs[0].FilePathNew)
+
+	commit.SHA = "fedcba98"
+	_, err = GetCommitGitDiffs(context.Background(), commit, newTestGitHubClient(t, testServer, ""), ParseOptions{})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGetCommitGitDiffs_Mock(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockCommitRaw: func(
+			ctx context.Context,
+			owner, repo, sha string,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			require.Equal(t, "0123abcd", sha)
+
+			return io.NopCloser(strings.NewReader("diff --git a/file1.go b/file1.go\nindex 1..2 100644\n")), nil, nil
+		},
+	}
+
+	gitDiffs, err := GetCommitGitDiffs(context.Background(), &CommitURL{Owner: "owner", Repo: "repo", SHA: "0123abcd"}, mockClient, ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "file1.go", gitDiffs[0].FilePathNew)
+}
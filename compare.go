@@ -0,0 +1,218 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// CompareURL identifies a comparison between two refs of a GitHub repository, such as two
+// tags, branches or commits.
+type CompareURL struct {
+	// Host is the host name of the GitHub instance, such as "github.com".
+	// An empty Host is treated as "github.com".
+	Host  string
+	Owner string
+	Repo  string
+
+	// Base is the ref the comparison starts from.
+	Base string
+
+	// Head is the ref the comparison ends at. Refs of a fork in the same
+	// network use the "user:branch" form.
+	Head string
+}
+
+// ParseCompareURL parses a GitHub compare URL, such as https://github.com/[owner]/[repo]/compare/[base]...[head],
+// and returns the host, owner, repository and the two refs being compared. Branch names
+// containing slashes, cross-fork heads of the form [user]:[branch], .diff and .patch suffixes,
+// and API URLs such as https://api.github.com/repos/[owner]/[repo]/compare/[base]...[head] are
+// accepted as well. The two-dot form [base]..[head] is rejected, since the diff retrieved for a
+// CompareURL is always the three-dot diff from the merge base of the two refs.
+//
+// Parameters:
+//   - compareURL: A string representing the URL of a GitHub comparison.
+//
+// Returns:
+//   - A pointer to a CompareURL struct containing the extracted information.
+//   - An error matching ErrInvalidURL if the URL is not a valid compare URL.
+//
+// Example:
+//
+//	cmp, err := ParseCompareURL("https://github.com/username/repository/compare/v1.0.0...v1.1.0")
+//	if err != nil {
+//	  // Handle error
+//	}
+//	gitDiffs, err := GetCompareGitDiffs(ctx, cmp, ghClient, ParseOptions{})
+func ParseCompareURL(compareURL string) (*CompareURL, error) {
+	cmp, ok := parseCompareReference(compareURL)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a compare URL", ErrInvalidURL, compareURL)
+	}
+
+	return cmp, nil
+}
+
+// parseCompareReference parses the forms accepted by ParseCompareURL.
+func parseCompareReference(reference string) (*CompareURL, bool) {
+	host, segments, err := splitGitHubURL(reference)
+	if err != nil {
+		return nil, false
+	}
+
+	owner, repo, ok := parseRepoSegments(segments)
+	if !ok || len(segments) < 4 || segments[2] != "compare" {
+		return nil, false
+	}
+
+	refs := strings.Join(segments[3:], "/")
+	refs = strings.TrimSuffix(strings.TrimSuffix(refs, ".diff"), ".patch")
+
+	// Refs cannot contain "..", so any left after the cut comes from a
+	// two-dot comparison.
+	base, head, ok := strings.Cut(refs, "...")
+	if !ok || base == "" || head == "" || strings.Contains(base, "..") || strings.Contains(head, "..") {
+		return nil, false
+	}
+
+	return &CompareURL{
+		Host:  host,
+		Owner: owner,
+		Repo:  repo,
+		Base:  base,
+		Head:  head,
+	}, true
+}
+
+// GitHubCompareClientInterface extends GitHubClientInterface with the ability to download
+// the raw diff or patch between two refs of a repository, as used by GetCompareGitDiffs.
+type GitHubCompareClientInterface interface {
+	GitHubClientInterface
+
+	// CompareRaw retrieves the raw diff or patch between the base and head refs of a
+	// repository, as selected by opts.Type. The caller must close the returned io.ReadCloser.
+	CompareRaw(
+		ctx context.Context,
+		owner string,
+		repo string,
+		base string,
+		head string,
+		opts github.RawOptions,
+	) (io.ReadCloser, *github.Response, error)
+}
+
+// CompareRaw streams the raw diff or patch between two refs from the compare API, using
+// the same authenticated transport as every other call made with the wrapped client. The
+// caller must close the returned io.ReadCloser.
+func (c *GitHubClientWrapper) CompareRaw(
+	ctx context.Context,
+	owner string,
+	repo string,
+	base string,
+	head string,
+	opts github.RawOptions,
+) (io.ReadCloser, *github.Response, error) {
+	path := fmt.Sprintf("repos/%v/%v/compare/%v...%v", owner, repo, escapeRef(base), escapeRef(head))
+
+	return c.getRaw(ctx, path, opts)
+}
+
+// CompareRaw calls the mock implementation of the CompareRaw method. If MockCompareRaw is
+// set to a custom function, that function is executed and its result returned. If
+// MockCompareRaw is not set, the method returns an error.
+func (m *MockGitClient) CompareRaw(
+	ctx context.Context,
+	owner string,
+	repo string,
+	base string,
+	head string,
+	opts github.RawOptions,
+) (io.ReadCloser, *github.Response, error) {
+	if m.MockCompareRaw != nil {
+		return m.MockCompareRaw(ctx, owner, repo, base, head, opts)
+	}
+
+	return nil, nil, errMockNotSet("MockCompareRaw")
+}
+
+// escapeRef escapes a ref for use in an API path, keeping the slashes of
+// branch names such as "feature/x".
+func escapeRef(ref string) string {
+	parts := strings.Split(ref, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// GetCompareGitDiffs retrieves the diff between two refs of a repository and parses it
+// into one GitDiff per file, in the same way as GetPullRequestGitDiffs does for pull
+// requests. It is equivalent to calling Fetcher.CompareGitDiffs on a Fetcher created with
+// WithGitHubClient(client).
+//
+// Parameters:
+//   - ctx: A context.Context object, used for managing the lifecycle of the request.
+//   - cmp: A pointer to a CompareURL struct, containing the owner, repository and the refs to compare.
+//   - client: An implementation of the GitHubClientInterface that also implements
+//     GitHubCompareClientInterface, such as GitHubClientWrapper.
+//   - opts: A ParseOptions struct containing the ignore list and other parsing settings.
+//
+// Returns:
+//   - A slice of GitDiff structs, each representing a parsed and non-ignored file diff.
+//   - An error if the client cannot compare refs or the diff cannot be retrieved, or a
+//     *ParseError if some file diffs could not be parsed.
+//
+// Example:
+//
+//	cmp := &CompareURL{Owner: "username", Repo: "repository", Base: "v1.0.0", Head: "v1.1.0"}
+//	gitDiffs, err := GetCompareGitDiffs(ctx, cmp, ghClient, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+func GetCompareGitDiffs(
+	ctx context.Context,
+	cmp *CompareURL,
+	client GitHubClientInterface,
+	opts ParseOptions,
+) ([]*GitDiff, error) {
+	fetcher, err := NewFetcher(WithGitHubClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.CompareGitDiffs(ctx, cmp, opts)
+}
+
+// CompareDiff retrieves the raw Git diff between two refs of a repository.
+func (f *Fetcher) CompareDiff(ctx context.Context, cmp *CompareURL) (string, error) {
+	client, err := f.gitHubClient(cmp.Host)
+	if err != nil {
+		return "", err
+	}
+
+	compareClient, ok := client.(GitHubCompareClientInterface)
+	if !ok {
+		return "", errors.New("client does not implement GitHubCompareClientInterface")
+	}
+
+	body, resp, err := compareClient.CompareRaw(ctx, cmp.Owner, cmp.Repo, cmp.Base, cmp.Head, github.RawOptions{Type: github.Diff})
+
+	return f.readRawResponse(body, resp, err)
+}
+
+// CompareGitDiffs retrieves the diff between two refs of a repository with CompareDiff and
+// parses it with ParseGitDiffE.
+func (f *Fetcher) CompareGitDiffs(ctx context.Context, cmp *CompareURL, opts ParseOptions) ([]*GitDiff, error) {
+	diff, err := f.CompareDiff(ctx, cmp)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGitDiffE(diff, opts)
+}
@@ -0,0 +1,135 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompareURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *CompareURL
+	}{
+		{
+			name:  "Tags",
+			input: "https://github.com/owner/repo/compare/v1.0.0...v1.1.0",
+			want:  &CompareURL{Host: "github.com", Owner: "owner", Repo: "repo", Base: "v1.0.0", Head: "v1.1.0"},
+		},
+		{
+			name:  "Branches with slashes",
+			input: "https://github.com/owner/repo/compare/release/1.x...feature/new-parser?expand=1",
+			want:  &CompareURL{Host: "github.com", Owner: "owner", Repo: "repo", Base: "release/1.x", Head: "feature/new-parser"},
+		},
+		{
+			name:  "Cross fork",
+			input: "https://github.com/owner/repo/compare/main...someone:fix-typo",
+			want:  &CompareURL{Host: "github.com", Owner: "owner", Repo: "repo", Base: "main", Head: "someone:fix-typo"},
+		},
+		{
+			name:  "Diff suffix",
+			input: "https://github.com/owner/repo/compare/v1.0.0...v1.1.0.diff",
+			want:  &CompareURL{Host: "github.com", Owner: "owner", Repo: "repo", Base: "v1.0.0", Head: "v1.1.0"},
+		},
+		{
+			name:  "API URL",
+			input: "https://api.github.com/repos/owner/repo/compare/abc123...def456",
+			want:  &CompareURL{Host: "github.com", Owner: "owner", Repo: "repo", Base: "abc123", Head: "def456"},
+		},
+		{
+			name:  "Enterprise",
+			input: "https://ghe.example.com/owner/repo/compare/a...b",
+			want:  &CompareURL{Host: "ghe.example.com", Owner: "owner", Repo: "repo", Base: "a", Head: "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCompareURL(tt.input)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseCompareURL_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"https://github.com/owner/repo",
+		"https://github.com/owner/repo/compare",
+		"https://github.com/owner/repo/compare/main",
+		"https://github.com/owner/repo/compare/...main",
+		"https://github.com/owner/repo/compare/main..develop",
+		"https://github.com/owner/repo/compare/main..develop...feature",
+		"https://github.com/owner/repo/pull/12",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			got, err := ParseCompareURL(input)
+
+			require.ErrorIs(t, err, ErrInvalidURL)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestGetCompareGitDiffs(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/repos/owner/repo/compare/release/1.x...someone:fix%23typo" ||
+			r.Header.Get("Accept") != "application/vnd.github.v3.diff" {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("diff --git a/file1.go b/file1.go\nindex 1..2 100644\n--- a/file1.go\n+++ b/file1.go\n@@ -1 +1 @@\n-a\n+b\n"))
+	}))
+	defer testServer.Close()
+
+	cmp := &CompareURL{Owner: "owner", Repo: "repo", Base: "release/1.x", Head: "someone:fix#typo"}
+
+	gitDiffs, err := GetCompareGitDiffs(context.Background(), cmp, newTestGitHubClient(t, testServer, ""), ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "file1.go", gitDiffs[0].FilePathNew)
+
+	cmp.Head = "missing"
+	_, err = GetCompareGitDiffs(context.Background(), cmp, newTestGitHubClient(t, testServer, ""), ParseOptions{})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGetCompareGitDiffs_Mock(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockCompareRaw: func(
+			ctx context.Context,
+			owner, repo, base, head string,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			require.Equal(t, "v1.0.0", base)
+			require.Equal(t, "v1.1.0", head)
+
+			return io.NopCloser(strings.NewReader("diff --git a/go.mod b/go.mod\nindex 1..2 100644\n")), nil, nil
+		},
+	}
+
+	cmp := &CompareURL{Owner: "owner", Repo: "repo", Base: "v1.0.0", Head: "v1.1.0"}
+
+	gitDiffs, err := GetCompareGitDiffs(context.Background(), cmp, mockClient, ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+
+	_, err = GetCompareGitDiffs(context.Background(), cmp, &MockGitClient{}, ParseOptions{})
+	require.EqualError(t, err, "MockGitClient: MockCompareRaw is not set")
+
+	_, err = GetCompareGitDiffs(context.Background(), cmp, &clientWithoutRaw{mock: mockClient}, ParseOptions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,318 @@
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"regexp"
+)
+
+// DiffReader parses a combined Git diff from an io.Reader one file at a
+// time, so that large diffs can be processed without holding the whole
+// diff in memory. Only the file diff currently being parsed is buffered,
+// and there is no limit on the length of individual lines unless
+// ParseOptions.MaxFileBytes is set.
+type DiffReader struct {
+	reader     *bufio.Reader
+	opts       ParseOptions
+	ignoreList []*regexp.Regexp
+	ignoreErr  error
+
+	// offset is the number of bytes read from reader so far.
+	offset int64
+
+	// next holds the "diff --git" line that ended the previous chunk and
+	// starts the next one, along with its offset.
+	next       []byte
+	nextOffset int64
+
+	err error
+}
+
+// NewDiffReader creates a DiffReader that parses the combined Git diff read
+// from r. File diffs are parsed and filtered according to opts in the same
+// way as ParseGitDiffE.
+//
+// Parameters:
+//   - r: An io.Reader providing the combined Git diff, such as an HTTP
+//     response body or the output of "git diff".
+//   - opts: A ParseOptions struct containing the ignore list and other
+//     parsing settings.
+//
+// Returns:
+//   - A pointer to a DiffReader. Call Next repeatedly to retrieve each file
+//     diff.
+//
+// Example:
+//
+//	reader := NewDiffReader(body, ParseOptions{IgnoreList: ignoreList})
+//	for {
+//	  gitDiff, err := reader.Next()
+//	  if err == io.EOF {
+//	    break
+//	  }
+//	  var chunkErr *ChunkError
+//	  if errors.As(err, &chunkErr) {
+//	    continue // The file diff could not be parsed
+//	  }
+//	  if err != nil {
+//	    // Handle error
+//	  }
+//	  // Process gitDiff
+//	}
+func NewDiffReader(r io.Reader, opts ParseOptions) *DiffReader {
+	ignoreList, err := compileIgnoreList(opts.IgnoreList)
+
+	return &DiffReader{
+		reader:     bufio.NewReader(r),
+		opts:       opts,
+		ignoreList: ignoreList,
+		ignoreErr:  err,
+	}
+}
+
+// Next returns the next parsed and non-ignored file diff.
+//
+// Returns:
+//   - A pointer to the next GitDiff struct.
+//   - io.EOF once the end of the diff has been reached.
+//   - A *ChunkError if the next file diff could not be parsed. Next can be
+//     called again to continue with the following file diff.
+//   - An error if an ignore pattern is invalid or reading from the
+//     underlying reader fails. These errors are returned by every
+//     subsequent call.
+func (r *DiffReader) Next() (*GitDiff, error) {
+	if r.ignoreErr != nil {
+		return nil, r.ignoreErr
+	}
+
+	for {
+		chunk, err := r.readChunk()
+		if err != nil {
+			return nil, err
+		}
+
+		gitDiff, err := parseGitDiffFileString(chunk.text, chunk.truncated, r.opts)
+		if err != nil {
+			return nil, newChunkError(chunk, err)
+		}
+
+		if matchCompiledIgnoreList(gitDiff, r.ignoreList) {
+			continue
+		}
+
+		return gitDiff, nil
+	}
+}
+
+// readChunk reads lines up to the next "diff --git" line or the end of the
+// input and returns them as a chunk. Chunks containing only whitespace are
+// skipped. Once the chunk exceeds ParseOptions.MaxFileBytes, the remaining
+// lines of its body are read and dropped rather than buffered, and the
+// chunk is marked as truncated.
+func (r *DiffReader) readChunk() (diffChunk, error) {
+	for {
+		if r.err != nil {
+			return diffChunk{}, r.err
+		}
+
+		builder := chunkBuilder{maxBytes: r.opts.MaxFileBytes}
+
+		start := r.nextOffset
+		if r.next != nil {
+			builder.writeLine(r.next)
+			r.next = nil
+		}
+
+		for {
+			lineOffset := r.offset
+
+			piece, err := r.reader.ReadSlice('\n')
+			r.offset += int64(len(piece))
+
+			if bytes.HasPrefix(piece, []byte("diff --git")) && builder.buf.Len() > 0 {
+				r.next, err = r.readFullLine(piece, err)
+				r.nextOffset = lineOffset
+
+				if err != nil && !errors.Is(err, io.EOF) {
+					r.err = err
+
+					return diffChunk{}, err
+				}
+
+				break
+			}
+
+			err = r.readLine(&builder, piece, err)
+
+			if errors.Is(err, io.EOF) {
+				r.err = io.EOF
+
+				break
+			}
+
+			if err != nil {
+				r.err = err
+
+				return diffChunk{}, err
+			}
+		}
+
+		if chunk, ok := newDiffChunk(builder.buf.String(), start); ok {
+			chunk.truncated = builder.truncated
+
+			return chunk, nil
+		}
+	}
+}
+
+// readLine adds the line starting with piece, returned by ReadSlice along
+// with err, to builder, reading the rest of the line in pieces.
+func (r *DiffReader) readLine(builder *chunkBuilder, piece []byte, err error) error {
+	builder.startLine(piece)
+
+	for {
+		builder.write(piece)
+
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			builder.endLine(err == nil)
+
+			return err
+		}
+
+		piece, err = r.reader.ReadSlice('\n')
+		r.offset += int64(len(piece))
+	}
+}
+
+// readFullLine returns a copy of the line starting with piece, returned by
+// ReadSlice along with err, reading the rest of the line.
+func (r *DiffReader) readFullLine(piece []byte, err error) ([]byte, error) {
+	line := append([]byte(nil), piece...)
+
+	for errors.Is(err, bufio.ErrBufferFull) {
+		piece, err = r.reader.ReadSlice('\n')
+		r.offset += int64(len(piece))
+		line = append(line, piece...)
+	}
+
+	return line, err
+}
+
+// chunkBuilder buffers the lines of a file diff read by a DiffReader, and
+// is also used by truncateFileDiff. Its header lines are always kept, but
+// its body is cut at the last line that fits within maxBytes, so that lines
+// past the limit, however long, are never buffered.
+type chunkBuilder struct {
+	buf      bytes.Buffer
+	maxBytes int64
+
+	// leading is the number of bytes of the blank lines before the first
+	// line of the file diff, which are trimmed by newDiffChunk and do not
+	// count towards maxBytes.
+	leading int
+
+	// inBody is set once a line that is not a header line has been read.
+	inBody bool
+
+	// cut is set once a line of the body has been dropped, after which
+	// every following line is dropped as well.
+	cut bool
+
+	// truncated is set once a line that newDiffChunk would not trim has
+	// been dropped.
+	truncated bool
+
+	// lineStart, kept, blank and spaced describe the line being read:
+	// where it starts in buf, whether it is kept regardless of maxBytes,
+	// whether it contains only whitespace so far and whether it starts
+	// with a space.
+	lineStart int
+	kept      bool
+	blank     bool
+	spaced    bool
+}
+
+// writeLine adds a complete line to the chunk.
+func (c *chunkBuilder) writeLine(line []byte) {
+	c.startLine(line)
+	c.write(line)
+	c.endLine(bytes.HasSuffix(line, []byte("\n")))
+}
+
+// startLine starts a new line, whose first piece is piece.
+func (c *chunkBuilder) startLine(piece []byte) {
+	c.lineStart = c.buf.Len()
+	c.blank = true
+	c.spaced = len(piece) > 0 && piece[0] == ' '
+
+	switch {
+	case c.inBody:
+		c.kept = false
+	case c.buf.Len() == c.leading && len(bytes.TrimSpace(piece)) == 0:
+		// Blank lines before the file diff are trimmed by newDiffChunk.
+		c.kept = true
+	default:
+		c.kept = isFileHeaderLine(string(piece))
+		c.inBody = !c.kept
+	}
+}
+
+// write adds a piece of the current line to the chunk, unless the line is
+// being dropped.
+func (c *chunkBuilder) write(piece []byte) {
+	if len(bytes.TrimSpace(piece)) > 0 {
+		c.blank = false
+	}
+
+	if c.kept || c.maxBytes <= 0 {
+		c.buf.Write(piece)
+
+		return
+	}
+
+	if c.cut {
+		return
+	}
+
+	// The line is dropped as soon as it is known not to fit, leaving room
+	// for a "\r\n" line ending that does not count towards the limit.
+	if int64(c.buf.Len()-c.leading+len(piece)) > c.maxBytes+2 {
+		c.buf.Truncate(c.lineStart)
+		c.cut = true
+
+		return
+	}
+
+	c.buf.Write(piece)
+}
+
+// endLine finishes the current line, dropping it if it does not fit within
+// maxBytes without its line ending. terminated reports whether the line
+// ends with a newline.
+func (c *chunkBuilder) endLine(terminated bool) {
+	if c.kept {
+		if !c.inBody && c.blank {
+			c.leading = c.buf.Len()
+		}
+
+		return
+	}
+
+	if !c.cut && c.maxBytes > 0 {
+		line := c.buf.Bytes()[c.lineStart:]
+		content := bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+
+		if int64(c.lineStart-c.leading+len(content)) > c.maxBytes {
+			c.buf.Truncate(c.lineStart)
+			c.cut = true
+		}
+	}
+
+	// As in trimBlankLines, blank lines only count as part of the diff
+	// when they may be empty context lines.
+	if c.cut && (!c.blank || (c.spaced && terminated)) {
+		c.truncated = true
+	}
+}
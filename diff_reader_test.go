@@ -0,0 +1,288 @@
+package github
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffReader_Next(t *testing.T) {
+	diff := `diff --git a/file1.go b/file1.go
+index 123abc..456def 100644
+--- a/file1.go
++++ b/file1.go
+@@ -1,3 +1,4 @@
++import "fmt"
+diff --git a/broken.go
+index 234bcd..567efg 100644
+diff --git a/go.mod b/go.mod
+index 234bcd..567efg 100644
+--- a/go.mod
++++ b/go.mod
+@@ -2,5 +2,6 @@
++module example.com/project
+diff --git a/file2.go b/file2.go
+index 345cde..678fgh 100644
+--- a/file2.go
++++ b/file2.go
+@@ -1 +1 @@
+-package a
++package b
+`
+
+	reader := NewDiffReader(strings.NewReader(diff), ParseOptions{IgnoreList: []string{`\.mod$`}})
+
+	gitDiff, err := reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, "file1.go", gitDiff.FilePathNew)
+
+	_, err = reader.Next()
+	var chunkErr *ChunkError
+	require.ErrorAs(t, err, &chunkErr)
+	require.Equal(t, int64(strings.Index(diff, "diff --git a/broken.go")), chunkErr.Offset)
+
+	gitDiff, err = reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, "file2.go", gitDiff.FilePathNew)
+	require.Equal(t, "--- a/file2.go\n+++ b/file2.go\n@@ -1 +1 @@\n-package a\n+package b", gitDiff.DiffContents)
+
+	_, err = reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+
+	_, err = reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestDiffReader_LongLines(t *testing.T) {
+	longLine := strings.Repeat("x", 1<<20)
+	diff := "diff --git a/min.js b/min.js\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/min.js\n" +
+		"+++ b/min.js\n" +
+		"@@ -1 +1 @@\n" +
+		"-" + longLine + "\n" +
+		"+" + longLine + "y"
+
+	reader := NewDiffReader(iotest.HalfReader(strings.NewReader(diff)), ParseOptions{})
+
+	gitDiff, err := reader.Next()
+	require.NoError(t, err)
+	require.Len(t, gitDiff.Hunks, 1)
+	require.Equal(t, longLine, gitDiff.Hunks[0].Lines[0].Content)
+	require.Equal(t, longLine+"y", gitDiff.Hunks[0].Lines[1].Content)
+
+	_, err = reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestDiffReader_Empty(t *testing.T) {
+	reader := NewDiffReader(strings.NewReader("\n\n"), ParseOptions{})
+
+	_, err := reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestDiffReader_ReadError(t *testing.T) {
+	readErr := errors.New("connection reset")
+	reader := NewDiffReader(iotest.ErrReader(readErr), ParseOptions{})
+
+	_, err := reader.Next()
+	require.ErrorIs(t, err, readErr)
+
+	_, err = reader.Next()
+	require.ErrorIs(t, err, readErr)
+}
+
+func TestDiffReader_InvalidIgnorePattern(t *testing.T) {
+	reader := NewDiffReader(strings.NewReader(""), ParseOptions{IgnoreList: []string{"[invalid-regex"}})
+
+	_, err := reader.Next()
+	require.ErrorContains(t, err, "invalid ignore pattern")
+}
+
+func TestDiffReader_MaxFileBytes(t *testing.T) {
+	diff := "diff --git a/big.go b/big.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/big.go\n" +
+		"+++ b/big.go\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		"-" + strings.Repeat("a", 100) + "\n" +
+		"+" + strings.Repeat("b", 100) + "\n" +
+		"+" + strings.Repeat("c", 100) + "\n" +
+		"diff --git a/small.go b/small.go\n" +
+		"index 3333333..4444444 100644\n" +
+		"--- a/small.go\n" +
+		"+++ b/small.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-a\n" +
+		"+b\n"
+
+	reader := NewDiffReader(strings.NewReader(diff), ParseOptions{MaxFileBytes: 210})
+
+	gitDiff, err := reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, "big.go", gitDiff.FilePathNew)
+	require.True(t, gitDiff.Truncated)
+	require.Len(t, gitDiff.Hunks, 1)
+	require.Len(t, gitDiff.Hunks[0].Lines, 1)
+	require.LessOrEqual(t, len(gitDiff.DiffContents), 210)
+
+	gitDiff, err = reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, "small.go", gitDiff.FilePathNew)
+	require.False(t, gitDiff.Truncated)
+	require.Len(t, gitDiff.Hunks[0].Lines, 2)
+
+	gitDiffs := ParseGitDiffWithOptions(diff, ParseOptions{MaxFileBytes: 210})
+	require.Len(t, gitDiffs, 2)
+	require.True(t, gitDiffs[0].Truncated)
+	require.Equal(t, gitDiff.DiffContents, gitDiffs[1].DiffContents)
+}
+
+func TestDiffReader_MaxFileBytesLongLine(t *testing.T) {
+	const lineBytes = 50 << 20
+
+	header := "diff --git a/big.go b/big.go\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/big.go\n" +
+		"+++ b/big.go\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+"
+	small := "\ndiff --git a/small.go b/small.go\n" +
+		"index 3333333..4444444 100644\n" +
+		"--- a/small.go\n" +
+		"+++ b/small.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-a\n" +
+		"+b\n"
+
+	reader := NewDiffReader(io.MultiReader(
+		strings.NewReader(header),
+		io.LimitReader(repeatByteReader('a'), lineBytes),
+		strings.NewReader(small),
+	), ParseOptions{MaxFileBytes: 150})
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	gitDiff, err := reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, "big.go", gitDiff.FilePathNew)
+	require.True(t, gitDiff.Truncated)
+	require.LessOrEqual(t, len(gitDiff.DiffContents), 150)
+
+	gitDiff, err = reader.Next()
+	require.NoError(t, err)
+	require.Equal(t, "small.go", gitDiff.FilePathNew)
+	require.False(t, gitDiff.Truncated)
+
+	// The long line is read in pieces and dropped rather than buffered.
+	runtime.ReadMemStats(&after)
+	require.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(lineBytes/10))
+
+	_, err = reader.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestDiffReader_MaxFileBytesEveryOffset(t *testing.T) {
+	first := "diff --git a/a.txt b/a.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/a.txt\n" +
+		"+++ b/a.txt\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		"-a\n" +
+		"+b\n" +
+		" c"
+	second := "diff --git a/b.txt b/b.txt\n" +
+		"new file mode 100644\n" +
+		"index 0000000..3333333\n" +
+		"--- /dev/null\n" +
+		"+++ b/b.txt\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+d"
+	diff := first + "\n" + second + "\n"
+
+	full := ParseGitDiff(diff, nil)
+	require.Len(t, full, 2)
+
+	for maxBytes := int64(1); maxBytes <= int64(len(diff))+1; maxBytes++ {
+		opts := ParseOptions{MaxFileBytes: maxBytes}
+
+		gitDiffs, err := ParseGitDiffE(diff, opts)
+		require.NoError(t, err, "MaxFileBytes %d", maxBytes)
+		require.Equal(t, ParseGitDiffWithOptions(diff, opts), gitDiffs, "MaxFileBytes %d", maxBytes)
+		require.Len(t, gitDiffs, 2, "MaxFileBytes %d", maxBytes)
+
+		for i, size := range []int{len(first), len(second)} {
+			require.Equal(t, full[i].FilePathNew, gitDiffs[i].FilePathNew, "MaxFileBytes %d", maxBytes)
+			require.Equal(t, full[i].Status, gitDiffs[i].Status, "MaxFileBytes %d", maxBytes)
+			require.Equal(t, maxBytes < int64(size), gitDiffs[i].Truncated, "MaxFileBytes %d", maxBytes)
+			require.True(t, strings.HasPrefix(full[i].DiffContents, gitDiffs[i].DiffContents), "MaxFileBytes %d", maxBytes)
+		}
+	}
+}
+
+func TestDiffReader_MaxFileBytesSmallerThanHeader(t *testing.T) {
+	diff := "diff --git a/old name.go b/new name.go\n" +
+		"similarity index 90%\n" +
+		"rename from old name.go\n" +
+		"rename to new name.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-a\n" +
+		"+b\n"
+
+	gitDiffs := ParseGitDiffWithOptions(diff, ParseOptions{MaxFileBytes: 10})
+	require.Len(t, gitDiffs, 1)
+
+	gitDiff, err := NewDiffReader(strings.NewReader(diff), ParseOptions{MaxFileBytes: 10}).Next()
+	require.NoError(t, err)
+	require.Equal(t, gitDiffs[0], gitDiff)
+
+	require.Equal(t, "old name.go", gitDiff.FilePathOld)
+	require.Equal(t, "new name.go", gitDiff.FilePathNew)
+	require.Equal(t, StatusRenamed, gitDiff.Status)
+	require.True(t, gitDiff.Truncated)
+	require.Empty(t, gitDiff.Hunks)
+}
+
+// repeatByteReader is an endless io.Reader of a single byte.
+type repeatByteReader byte
+
+func (b repeatByteReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+
+	return len(p), nil
+}
+
+func TestParseGitDiffWithOptions_MatchesParseGitDiffE(t *testing.T) {
+	emptyContext := "diff --git a/f b/f\nindex 1..2 100644\n--- a/f\n+++ b/f\n" +
+		"@@ -1,3 +1,3 @@\n z\n\n\n@@ -1,2 +1,2 @@\n y\n y\n"
+	twoFiles := "diff --git a/a.txt b/a.txt\nindex 1..2 100644\n--- a/a.txt\n+++ b/a.txt\n" +
+		"@@ -1,2 +1,2 @@\n-a\n+b\n c \n\n" +
+		"diff --git a/b.bin b/b.bin\nnew file mode 100644\nindex 0..3\nBinary files /dev/null and b/b.bin differ\n"
+
+	diffs := map[string]string{
+		"Empty context lines":      emptyContext,
+		"Empty context lines CRLF": strings.ReplaceAll(emptyContext, "\n", "\r\n"),
+		"Two files":                twoFiles,
+		"Two files CRLF":           strings.ReplaceAll(twoFiles, "\n", "\r\n"),
+	}
+
+	for name, diff := range diffs {
+		t.Run(name, func(t *testing.T) {
+			for maxBytes := int64(0); maxBytes <= int64(len(diff))+2; maxBytes++ {
+				opts := ParseOptions{MaxFileBytes: maxBytes}
+
+				gitDiffs, _ := ParseGitDiffE(diff, opts)
+				require.Equal(t, gitDiffs, ParseGitDiffWithOptions(diff, opts), "MaxFileBytes %d", maxBytes)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package github
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiffSource produces the parsed file diffs of a change, independently of where the change
+// is hosted. It is implemented by GitHubPullRequestSource for GitHub pull requests,
+// GitLabMergeRequestSource for GitLab merge requests, BitbucketPullRequestSource for
+// Bitbucket pull requests and LocalSource for local repositories, so that code reviewing
+// diffs does not need to know about any particular provider.
+type DiffSource interface {
+	// GitDiffs retrieves the diff of the change and parses it into one GitDiff per file,
+	// dropping the files matched by opts.IgnoreList. A *ParseError is returned along with
+	// the remaining file diffs if some file diffs could not be parsed.
+	GitDiffs(ctx context.Context, opts ParseOptions) ([]*GitDiff, error)
+}
+
+var (
+	_ DiffSource = (*GitHubPullRequestSource)(nil)
+	_ DiffSource = (*GitLabMergeRequestSource)(nil)
+	_ DiffSource = (*BitbucketPullRequestSource)(nil)
+	_ DiffSource = (*LocalSource)(nil)
+)
+
+// GitHubPullRequestSource is the DiffSource of a GitHub pull request.
+type GitHubPullRequestSource struct {
+	// Fetcher retrieves the diff. A nil Fetcher behaves like one created by
+	// NewFetcher without options.
+	Fetcher *Fetcher
+
+	// PullRequest identifies the pull request.
+	PullRequest *PullRequestURL
+}
+
+// GitDiffs retrieves the diff of the pull request with Fetcher.PullRequestGitDiffs.
+func (s *GitHubPullRequestSource) GitDiffs(ctx context.Context, opts ParseOptions) ([]*GitDiff, error) {
+	return fetcherOrDefault(s.Fetcher).PullRequestGitDiffs(ctx, s.PullRequest, opts)
+}
+
+// NewDiffSource creates the DiffSource for the URL of a GitHub pull request, a GitLab merge
+// request or a Bitbucket pull request, as accepted by ParsePullRequestURL, ParseMergeRequestURL
+// and ParseBitbucketPullRequestURL. The options configure the Fetcher used to retrieve the
+// diff, as for NewFetcher.
+//
+// Parameters:
+//   - rawURL: A string representing the URL of a pull request or merge request.
+//   - opts: The options configuring the Fetcher, such as WithToken, WithGitLabToken or
+//     WithHTTPClient. Each provider only uses its own token and base URL options.
+//
+// Returns:
+//   - A DiffSource for the pull request or merge request.
+//   - An error matching ErrInvalidURL if the URL is not a supported pull request or merge
+//     request URL, or an error if one of the options is invalid.
+//
+// Example:
+//
+//	source, err := NewDiffSource(
+//	  os.Args[1],
+//	  WithToken(os.Getenv("GITHUB_TOKEN")),
+//	  WithGitLabToken(os.Getenv("GITLAB_TOKEN")),
+//	  WithBitbucketToken(os.Getenv("BITBUCKET_TOKEN")),
+//	)
+//	if err != nil {
+//	  // Handle error
+//	}
+//	gitDiffs, err := source.GitDiffs(ctx, ParseOptions{IgnoreList: ignoreList})
+func NewDiffSource(rawURL string, opts ...FetcherOption) (DiffSource, error) {
+	fetcher, err := NewFetcher(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if mr, ok := parseMergeRequestReference(rawURL); ok {
+		return &GitLabMergeRequestSource{Fetcher: fetcher, MergeRequest: mr}, nil
+	}
+
+	if pr, ok := parseBitbucketPullRequestReference(rawURL); ok {
+		return &BitbucketPullRequestSource{Fetcher: fetcher, PullRequest: pr}, nil
+	}
+
+	if pr, ok := parsePullRequestReference(rawURL); ok {
+		return &GitHubPullRequestSource{Fetcher: fetcher, PullRequest: pr}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q is not a pull request or merge request URL", ErrInvalidURL, rawURL)
+}
+
+// fetcherOrDefault returns f, or a Fetcher without options if f is nil.
+func fetcherOrDefault(f *Fetcher) *Fetcher {
+	if f == nil {
+		return &Fetcher{}
+	}
+
+	return f
+}
@@ -0,0 +1,58 @@
+package github
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiffSource(t *testing.T) {
+	source, err := NewDiffSource("https://gitlab.example.com/group/sub/project/-/merge_requests/42", WithGitLabToken("secret"))
+	require.NoError(t, err)
+	require.IsType(t, &GitLabMergeRequestSource{}, source)
+	require.Equal(t, &MergeRequestURL{Host: "gitlab.example.com", Project: "group/sub/project", IID: 42}, source.(*GitLabMergeRequestSource).MergeRequest)
+	require.Equal(t, "secret", source.(*GitLabMergeRequestSource).Fetcher.gitLabToken)
+
+	source, err = NewDiffSource("https://github.com/owner/repo/pull/12")
+	require.NoError(t, err)
+	require.IsType(t, &GitHubPullRequestSource{}, source)
+	require.Equal(t, &PullRequestURL{Host: "github.com", Owner: "owner", Repo: "repo", PRNumber: 12}, source.(*GitHubPullRequestSource).PullRequest)
+
+	source, err = NewDiffSource("https://bitbucket.example.com/projects/PROJ/repos/repo/pull-requests/7")
+	require.NoError(t, err)
+	require.IsType(t, &BitbucketPullRequestSource{}, source)
+	require.Equal(t, &BitbucketPullRequestURL{Host: "bitbucket.example.com", Server: true, Owner: "PROJ", Repo: "repo", ID: 7}, source.(*BitbucketPullRequestSource).PullRequest)
+
+	_, err = NewDiffSource("https://gitlab.example.com/group/project/-/issues/1")
+	require.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = NewDiffSource("https://github.com/owner/repo/pull/12", WithBaseURL("ftp://example.com"))
+	require.ErrorIs(t, err, ErrInvalidURL)
+}
+
+func TestGitHubPullRequestSource(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockGetRaw: func(ctx context.Context, owner, repo string, number int, opts github.RawOptions) (io.ReadCloser, *github.Response, error) {
+			require.Equal(t, 12, number)
+
+			return io.NopCloser(strings.NewReader(
+				"diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b\n" +
+					"diff --git a/README.md b/README.md\nindex 3..4 100644\n--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-c\n+d\n",
+			)), nil, nil
+		},
+	}
+
+	var source DiffSource = &GitHubPullRequestSource{
+		Fetcher:     mustNewFetcher(t, WithGitHubClient(mockClient)),
+		PullRequest: &PullRequestURL{Owner: "owner", Repo: "repo", PRNumber: 12},
+	}
+
+	gitDiffs, err := source.GitDiffs(context.Background(), ParseOptions{IgnoreList: []string{`\.md$`}})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "main.go", gitDiffs[0].FilePathNew)
+}
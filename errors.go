@@ -0,0 +1,216 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+var (
+	// ErrInvalidURL is returned when a URL or reference cannot be parsed.
+	ErrInvalidURL = errors.New("invalid URL")
+
+	// ErrNotFound is matched by errors for resources that do not exist or
+	// are not visible to the client, such as a 404 response.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnauthorized is matched by errors for requests that were rejected
+	// because of missing or insufficient credentials, such as a 401
+	// response or a 403 response that is not caused by rate limiting.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrRateLimited is matched by errors for requests rejected by the
+	// primary or secondary GitHub rate limits.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrDiffTooLarge is matched by errors for diffs that GitHub refuses to
+	// render because they exceed its size limits.
+	ErrDiffTooLarge = errors.New("diff too large")
+)
+
+// DiffSizeError is returned when a diff is larger than the configured size
+// limit, such as the one set with WithMaxBytes. It matches ErrDiffTooLarge
+// with errors.Is.
+type DiffSizeError struct {
+	// Limit is the maximum size of the diff in bytes.
+	Limit int64
+
+	// Size is the size of the diff announced by the Content-Length header
+	// of the response, or -1 if the limit was exceeded while reading a
+	// response of unknown length.
+	Size int64
+}
+
+// Error returns a description of the exceeded limit.
+func (e *DiffSizeError) Error() string {
+	if e.Size < 0 {
+		return fmt.Sprintf("diff is larger than the limit of %d bytes", e.Limit)
+	}
+
+	return fmt.Sprintf("diff of %d bytes is larger than the limit of %d bytes", e.Size, e.Limit)
+}
+
+// Is reports whether target is ErrDiffTooLarge.
+func (e *DiffSizeError) Is(target error) bool {
+	return target == ErrDiffTooLarge
+}
+
+// maxErrorBodySnippet is the maximum number of bytes of a response body
+// kept in an HTTPStatusError.
+const maxErrorBodySnippet = 512
+
+// HTTPStatusError is returned when GitHub responds with an unsuccessful
+// HTTP status code. It can be inspected with errors.As for the status code
+// and response body, and matches ErrNotFound, ErrUnauthorized,
+// ErrRateLimited and ErrDiffTooLarge with errors.Is where appropriate.
+type HTTPStatusError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Body is the beginning of the response body, truncated to a few
+	// hundred bytes.
+	Body string
+
+	// RateLimited is true if the response indicated that a rate limit was
+	// exceeded, for example through an X-RateLimit-Remaining header of 0.
+	RateLimited bool
+
+	// Err is the underlying error reported by the GitHub client, if any.
+	Err error
+}
+
+// Error returns a description of the failed response.
+func (e *HTTPStatusError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected HTTP status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("unexpected HTTP status %d: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns the underlying error reported by the GitHub client.
+func (e *HTTPStatusError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the status code of the response corresponds to one
+// of the package's sentinel errors.
+func (e *HTTPStatusError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized ||
+			(e.StatusCode == http.StatusForbidden && !e.RateLimited)
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests || e.RateLimited
+	case ErrDiffTooLarge:
+		return e.StatusCode == http.StatusNotAcceptable || isDiffTooLargeMessage(e.Body)
+	default:
+		return false
+	}
+}
+
+// newHTTPStatusError creates an HTTPStatusError from an unsuccessful
+// response, reading the beginning of its body. The caller remains
+// responsible for closing the body.
+func newHTTPStatusError(resp *http.Response) *HTTPStatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySnippet))
+
+	return &HTTPStatusError{
+		StatusCode:  resp.StatusCode,
+		Body:        strings.TrimSpace(string(body)),
+		RateLimited: isRateLimitedResponse(resp) || isSecondaryRateLimitMessage(string(body)),
+	}
+}
+
+// wrapGitHubError converts an error returned by a GitHubClientInterface
+// call into an *HTTPStatusError when the call produced an unsuccessful
+// response, so that it can be matched against the sentinel errors. Errors
+// without a response, such as network errors, are returned unchanged.
+func wrapGitHubError(resp *github.Response, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return err
+	}
+
+	var (
+		rateLimitErr *github.RateLimitError
+		abuseErr     *github.AbuseRateLimitError
+		errorResp    *github.ErrorResponse
+	)
+
+	switch {
+	case errors.As(err, &rateLimitErr), errors.As(err, &abuseErr):
+		statusErr = &HTTPStatusError{StatusCode: http.StatusForbidden, RateLimited: true, Err: err}
+	case errors.As(err, &errorResp) && errorResp.Response != nil:
+		statusErr = &HTTPStatusError{
+			StatusCode:  errorResp.Response.StatusCode,
+			Body:        errorResp.Message,
+			RateLimited: isRateLimitedResponse(errorResp.Response) || isSecondaryRateLimitMessage(errorResp.Message),
+			Err:         err,
+		}
+
+		for _, e := range errorResp.Errors {
+			if e.Code == "too_large" {
+				statusErr.Body = strings.TrimSpace(statusErr.Body + " (too_large)")
+			}
+		}
+	case resp != nil && resp.Response != nil && resp.StatusCode >= http.StatusBadRequest:
+		statusErr = &HTTPStatusError{
+			StatusCode:  resp.StatusCode,
+			RateLimited: isRateLimitedResponse(resp.Response) || resp.Rate.Limit > 0 && resp.Rate.Remaining == 0,
+			Err:         err,
+		}
+	default:
+		return err
+	}
+
+	return statusErr
+}
+
+// isRateLimitedResponse reports whether a response was rejected by one of
+// GitHub's rate limits, based on its status code and headers.
+func isRateLimitedResponse(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	if resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+
+	return resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""
+}
+
+// isSecondaryRateLimitMessage reports whether a response body contains the
+// message GitHub uses when a request is rejected by its secondary rate
+// limits, which is often the only sign of them.
+func isSecondaryRateLimitMessage(body string) bool {
+	body = strings.ToLower(body)
+
+	return strings.Contains(body, "secondary rate limit") ||
+		strings.Contains(body, "abuse detection mechanism")
+}
+
+// isDiffTooLargeMessage reports whether a response body contains one of
+// the messages GitHub uses when a diff exceeds its size limits.
+func isDiffTooLargeMessage(body string) bool {
+	body = strings.ToLower(body)
+
+	return strings.Contains(body, "too_large") ||
+		strings.Contains(body, "diff exceeded the maximum") ||
+		strings.Contains(body, "diff is taking too long")
+}
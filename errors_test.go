@@ -0,0 +1,161 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPStatusError_Is(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *HTTPStatusError
+		want []error
+	}{
+		{name: "Not found", err: &HTTPStatusError{StatusCode: 404}, want: []error{ErrNotFound}},
+		{name: "Unauthorized", err: &HTTPStatusError{StatusCode: 401}, want: []error{ErrUnauthorized}},
+		{name: "Forbidden", err: &HTTPStatusError{StatusCode: 403}, want: []error{ErrUnauthorized}},
+		{name: "Rate limited", err: &HTTPStatusError{StatusCode: 403, RateLimited: true}, want: []error{ErrRateLimited}},
+		{name: "Too many requests", err: &HTTPStatusError{StatusCode: 429}, want: []error{ErrRateLimited}},
+		{name: "Not acceptable", err: &HTTPStatusError{StatusCode: 406}, want: []error{ErrDiffTooLarge}},
+		{
+			name: "Too large message",
+			err:  &HTTPStatusError{StatusCode: 422, Body: "Sorry, the diff exceeded the maximum number of lines (20000)"},
+			want: []error{ErrDiffTooLarge},
+		},
+		{name: "Server error", err: &HTTPStatusError{StatusCode: 500}},
+	}
+
+	sentinels := []error{ErrNotFound, ErrUnauthorized, ErrRateLimited, ErrDiffTooLarge, ErrInvalidURL}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, sentinel := range sentinels {
+				require.Equal(t, contains(tt.want, sentinel), errors.Is(tt.err, sentinel), sentinel.Error())
+			}
+		})
+	}
+}
+
+func contains(errs []error, target error) bool {
+	for _, err := range errs {
+		if err == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestFetcher_DiffURLHTTPStatusError(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such pull request", http.StatusNotFound)
+	}))
+	defer testServer.Close()
+
+	fetcher, err := NewFetcher(WithGitHubClient(&clientWithoutRaw{mock: &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{DiffURL: github.String(testServer.URL + "/123.diff")}, nil, nil
+		},
+	}}))
+	require.NoError(t, err)
+
+	_, err = fetcher.Diff(context.Background(), &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123})
+
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	require.Equal(t, "no such pull request", statusErr.Body)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.EqualError(t, err, "unexpected HTTP status 404: no such pull request")
+}
+
+func TestGetPullRequestWithClient_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    error
+		status  int
+	}{
+		{
+			name: "Unauthorized",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+			},
+			want:   ErrUnauthorized,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name: "Rate limited",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-RateLimit-Limit", "60")
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("X-RateLimit-Reset", "4102444800")
+				http.Error(w, `{"message": "API rate limit exceeded"}`, http.StatusForbidden)
+			},
+			want:   ErrRateLimited,
+			status: http.StatusForbidden,
+		},
+		{
+			name: "Diff too large",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotAcceptable)
+				_, _ = w.Write([]byte(`{"message": "Sorry, the diff exceeded the maximum number of lines (20000)",` +
+					`"errors": [{"resource": "PullRequest", "field": "diff", "code": "too_large"}]}`))
+			},
+			want:   ErrDiffTooLarge,
+			status: http.StatusNotAcceptable,
+		},
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testServer := httptest.NewServer(tt.handler)
+			defer testServer.Close()
+
+			_, err := GetPullRequestWithClient(context.Background(), prURL, newTestGitHubClient(t, testServer, ""))
+			require.ErrorIs(t, err, tt.want)
+
+			var statusErr *HTTPStatusError
+			require.ErrorAs(t, err, &statusErr)
+			require.Equal(t, tt.status, statusErr.StatusCode)
+		})
+	}
+}
+
+func TestGetPullRequestWithDetails_NotFoundIsErrNotFound(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, errors.New("pull request not found")
+		},
+	}
+
+	_, err := GetPullRequestWithDetails(context.Background(), &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 1}, mockClient)
+
+	require.ErrorIs(t, err, ErrNotFound)
+	require.EqualError(t, err, "pull request not found")
+}
+
+func TestWrapGitHubError_WithoutResponse(t *testing.T) {
+	netErr := errors.New("connection refused")
+
+	require.Same(t, netErr, wrapGitHubError(nil, netErr))
+	require.NoError(t, wrapGitHubError(nil, nil))
+}
+
+func TestDiffSizeError(t *testing.T) {
+	err := error(&DiffSizeError{Limit: 10, Size: 20})
+
+	require.ErrorIs(t, err, ErrDiffTooLarge)
+	require.NotErrorIs(t, err, ErrNotFound)
+	require.EqualError(t, err, "diff of 20 bytes is larger than the limit of 10 bytes")
+	require.EqualError(t, &DiffSizeError{Limit: 10, Size: -1}, "diff is larger than the limit of 10 bytes")
+}
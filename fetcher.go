@@ -0,0 +1,533 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// Fetcher retrieves pull request diffs, patches and details from GitHub. It is created with
+// NewFetcher and configured with functional options, such as WithToken or WithHTTPClient.
+// A Fetcher is safe for concurrent use as long as its GitHub client is.
+type Fetcher struct {
+	client      GitHubClientInterface
+	httpClient  *http.Client
+	token       string
+	baseURL     *url.URL
+	userAgent   string
+	maxBytes    int64
+	retryPolicy *RetryPolicy
+
+	gitLabToken     string
+	gitLabAPIURL    *url.URL
+	bitbucketToken  string
+	bitbucketAPIURL *url.URL
+}
+
+// FetcherOption configures a Fetcher created by NewFetcher.
+type FetcherOption func(*Fetcher) error
+
+// WithGitHubClient makes the Fetcher use the given client for every GitHub API request
+// instead of creating one, so WithToken, WithBaseURL and WithUserAgent have no effect on
+// GitHub requests. WithHTTPClient only applies to the diffs downloaded through the diff URL
+// of a pull request when the client cannot download them itself. If the client is a
+// *GitHubClientWrapper, the Fetcher uses a copy of it whose requests are retried with the
+// policy set by WithRetry, or with DefaultRetryPolicy unless its transport already retries
+// with a RetryTransport, possibly wrapped by other transports.
+func WithGitHubClient(client GitHubClientInterface) FetcherOption {
+	return func(f *Fetcher) error {
+		f.client = client
+
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for every request. Use it to set a timeout or an
+// authenticating transport. Requests are not retried unless WithRetry is also used.
+func WithHTTPClient(httpClient *http.Client) FetcherOption {
+	return func(f *Fetcher) error {
+		f.httpClient = httpClient
+
+		return nil
+	}
+}
+
+// WithToken authenticates GitHub API requests with the given personal access token or
+// installation token, which is required for private repositories. It is not sent to other
+// providers; use WithGitLabToken and WithBitbucketToken for them.
+func WithToken(token string) FetcherOption {
+	return func(f *Fetcher) error {
+		f.token = token
+
+		return nil
+	}
+}
+
+// WithBaseURL sets the base URL of the GitHub API, such as "https://ghe.example.com/api/v3/".
+// By default the API of the host of each pull request is used, as with NewGitHubClientForHost.
+// It does not apply to other providers; use WithGitLabBaseURL and WithBitbucketBaseURL for them.
+func WithBaseURL(baseURL string) FetcherOption {
+	return func(f *Fetcher) error {
+		parsed, err := parseBaseURL(baseURL)
+		if err != nil {
+			return err
+		}
+
+		f.baseURL = parsed
+
+		return nil
+	}
+}
+
+// WithGitLabToken authenticates GitLab API requests with the given personal, project or
+// group access token, which is sent in the PRIVATE-TOKEN header.
+func WithGitLabToken(token string) FetcherOption {
+	return func(f *Fetcher) error {
+		f.gitLabToken = token
+
+		return nil
+	}
+}
+
+// WithGitLabBaseURL sets the base URL of the GitLab API, such as
+// "https://gitlab.example.com/api/v4/". By default the API of the host of each merge request
+// is used.
+func WithGitLabBaseURL(baseURL string) FetcherOption {
+	return func(f *Fetcher) error {
+		parsed, err := parseBaseURL(baseURL)
+		if err != nil {
+			return err
+		}
+
+		f.gitLabAPIURL = parsed
+
+		return nil
+	}
+}
+
+// WithBitbucketToken authenticates Bitbucket API requests with the given access token, which
+// is sent as a bearer token.
+func WithBitbucketToken(token string) FetcherOption {
+	return func(f *Fetcher) error {
+		f.bitbucketToken = token
+
+		return nil
+	}
+}
+
+// WithBitbucketBaseURL sets the base URL of the Bitbucket API, such as
+// "https://bitbucket.example.com/rest/api/latest/". By default the API of Bitbucket Cloud, or
+// of the host of each Bitbucket Server pull request, is used.
+func WithBitbucketBaseURL(baseURL string) FetcherOption {
+	return func(f *Fetcher) error {
+		parsed, err := parseBaseURL(baseURL)
+		if err != nil {
+			return err
+		}
+
+		f.bitbucketAPIURL = parsed
+
+		return nil
+	}
+}
+
+// parseBaseURL parses the base URL of an API, adding the trailing slash
+// that relative API paths are resolved against.
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidURL, parsed.Scheme)
+	}
+
+	return parsed, nil
+}
+
+// WithMaxBytes limits the size of the diffs and patches read by the Fetcher. A response
+// whose Content-Length exceeds the limit is rejected before its body is read, and reading
+// past the limit fails otherwise. In both cases the error is a *DiffSizeError, which
+// matches ErrDiffTooLarge. A limit of 0 or less means no limit.
+func WithMaxBytes(maxBytes int64) FetcherOption {
+	return func(f *Fetcher) error {
+		f.maxBytes = maxBytes
+
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with API requests.
+func WithUserAgent(userAgent string) FetcherOption {
+	return func(f *Fetcher) error {
+		f.userAgent = userAgent
+
+		return nil
+	}
+}
+
+// WithRetry retries failed requests according to the given policy. By default, a Fetcher
+// created without WithHTTPClient retries requests with DefaultRetryPolicy; use a policy
+// with MaxAttempts set to 1 to disable retries.
+func WithRetry(policy RetryPolicy) FetcherOption {
+	return func(f *Fetcher) error {
+		f.retryPolicy = &policy
+
+		return nil
+	}
+}
+
+// NewFetcher creates a Fetcher configured with the given options.
+//
+// Parameters:
+//   - opts: The options configuring the Fetcher, such as WithToken, WithHTTPClient,
+//     WithBaseURL, WithMaxBytes, WithUserAgent or WithRetry, or the GitLab and Bitbucket
+//     options such as WithGitLabToken.
+//
+// Returns:
+//   - A pointer to the configured Fetcher.
+//   - An error if one of the options is invalid, such as a malformed base URL.
+//
+// Example:
+//
+//	fetcher, err := NewFetcher(
+//	  WithToken(os.Getenv("GITHUB_TOKEN")),
+//	  WithHTTPClient(&http.Client{Timeout: 30 * time.Second}),
+//	  WithRetry(DefaultRetryPolicy()),
+//	  WithMaxBytes(10 << 20),
+//	)
+//	if err != nil {
+//	  // Handle error
+//	}
+//	diff, err := fetcher.Diff(ctx, prURL)
+func NewFetcher(opts ...FetcherOption) (*Fetcher, error) {
+	f := &Fetcher{}
+
+	for _, opt := range opts {
+		if err := opt(f); err != nil {
+			return nil, err
+		}
+	}
+
+	if wrapper, ok := f.client.(*GitHubClientWrapper); ok && wrapper != nil && wrapper.Client != nil {
+		f.client = retryingGitHubClient(wrapper, f.retryPolicy)
+	}
+
+	return f, nil
+}
+
+// retryingGitHubClient returns a copy of client whose requests are retried
+// according to policy, or DefaultRetryPolicy if policy is nil. The
+// transport of its HTTP client, along with any authentication it adds, is
+// kept as the base transport of a RetryTransport. A client whose transport
+// is a RetryTransport is returned as is unless a policy is given, which then
+// replaces the existing one. A RetryTransport hidden under other
+// transports, such as the one added by github.Client.WithAuthToken, cannot
+// be replaced, so it is left to retry requests on its own unless a policy is
+// given, in which case it sends them once.
+func retryingGitHubClient(client *GitHubClientWrapper, policy *RetryPolicy) *GitHubClientWrapper {
+	httpClient := client.Client.Client()
+
+	transport := httpClient.Transport
+	if retry, ok := transport.(*RetryTransport); ok {
+		if policy == nil {
+			return client
+		}
+
+		transport = retry.Base
+	}
+
+	retryPolicy := DefaultRetryPolicy()
+	if policy != nil {
+		retryPolicy = *policy
+	}
+
+	retryTransport := NewRetryTransport(transport, retryPolicy)
+	retryTransport.overrideNested = policy != nil
+	httpClient.Transport = retryTransport
+
+	rebuilt := github.NewClient(httpClient)
+	rebuilt.BaseURL = client.BaseURL
+	rebuilt.UploadURL = client.UploadURL
+	rebuilt.UserAgent = client.UserAgent
+
+	return &GitHubClientWrapper{Client: rebuilt}
+}
+
+// Diff retrieves the raw Git diff of a pull request. The diff is downloaded with GetRaw if
+// the GitHub client implements GitHubRawClientInterface, as the clients created by the
+// Fetcher do, and from the diff URL in the pull request details otherwise. Use
+// PullRequestGitDiffs to fall back to the files of the pull request when GitHub refuses to
+// render the diff.
+func (f *Fetcher) Diff(ctx context.Context, pr *PullRequestURL) (string, error) {
+	body, err := f.DiffReader(ctx, pr)
+	if err != nil {
+		return "", err
+	}
+
+	return readDiffBody(body)
+}
+
+// DiffReader opens the raw Git diff of a pull request for streaming, in the same way as Diff.
+// The caller must close the returned io.ReadCloser.
+func (f *Fetcher) DiffReader(ctx context.Context, pr *PullRequestURL) (io.ReadCloser, error) {
+	return f.openRaw(ctx, pr, github.Diff)
+}
+
+// Patch retrieves the pull request as a patch series in mailbox format, with one message
+// per commit, in the same way as Diff.
+func (f *Fetcher) Patch(ctx context.Context, pr *PullRequestURL) (string, error) {
+	body, err := f.openRaw(ctx, pr, github.Patch)
+	if err != nil {
+		return "", err
+	}
+
+	return readDiffBody(body)
+}
+
+// PullRequest retrieves the details of a pull request.
+func (f *Fetcher) PullRequest(ctx context.Context, pr *PullRequestURL) (*github.PullRequest, error) {
+	client, err := f.gitHubClient(pr.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	pullRequest, resp, err := client.Get(ctx, pr.Owner, pr.Repo, pr.PRNumber)
+	if err != nil {
+		return nil, wrapGitHubError(resp, err)
+	}
+
+	return pullRequest, nil
+}
+
+// openRaw opens the raw diff or patch of a pull request, applying the
+// Fetcher's size limit.
+func (f *Fetcher) openRaw(ctx context.Context, pr *PullRequestURL, rawType github.RawType) (io.ReadCloser, error) {
+	client, err := f.gitHubClient(pr.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		body          io.ReadCloser
+		contentLength int64
+	)
+
+	if raw, ok := client.(GitHubRawClientInterface); ok {
+		var resp *github.Response
+
+		body, resp, err = raw.GetRaw(ctx, pr.Owner, pr.Repo, pr.PRNumber, github.RawOptions{Type: rawType})
+		if err != nil {
+			return nil, wrapGitHubError(resp, err)
+		}
+
+		contentLength = responseContentLength(resp)
+	} else {
+		pullRequest, resp, err := client.Get(ctx, pr.Owner, pr.Repo, pr.PRNumber)
+		if err != nil {
+			return nil, wrapGitHubError(resp, err)
+		}
+
+		rawURL := pullRequest.GetDiffURL()
+		if rawType == github.Patch {
+			rawURL = pullRequest.GetPatchURL()
+		}
+
+		httpResp, err := openDiffURLWithClient(ctx, f.downloadHTTPClient(), rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		body, contentLength = httpResp.Body, httpResp.ContentLength
+	}
+
+	return limitDiffBody(body, contentLength, f.maxBytes)
+}
+
+// readRawResponse reads the body returned by one of the raw download
+// methods of a GitHub client, applying the Fetcher's size limit.
+func (f *Fetcher) readRawResponse(body io.ReadCloser, resp *github.Response, err error) (string, error) {
+	if err != nil {
+		return "", wrapGitHubError(resp, err)
+	}
+
+	body, err = limitDiffBody(body, responseContentLength(resp), f.maxBytes)
+	if err != nil {
+		return "", err
+	}
+
+	return readDiffBody(body)
+}
+
+// openProviderURL makes a GET request to the API of a provider other than
+// GitHub with the given Accept and authentication headers, and returns the
+// body of a successful response with the Fetcher's size limit applied.
+func (f *Fetcher) openProviderURL(
+	ctx context.Context,
+	rawURL string,
+	accept string,
+	header http.Header,
+) (io.ReadCloser, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	req.Header.Set("Accept", accept)
+
+	if f.userAgent != "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	resp, err := f.apiHTTPClient().Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer closeBody(resp.Body)
+
+		return nil, nil, newHTTPStatusError(resp)
+	}
+
+	body, err := limitDiffBody(resp.Body, resp.ContentLength, f.maxBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, resp, nil
+}
+
+// gitHubClient returns the client configured with WithGitHubClient, or
+// creates one for the given host from the other options.
+func (f *Fetcher) gitHubClient(host string) (GitHubClientInterface, error) {
+	if f.client != nil {
+		return f.client, nil
+	}
+
+	httpClient := f.apiHTTPClient()
+
+	var (
+		wrapper *GitHubClientWrapper
+		err     error
+	)
+
+	if f.baseURL != nil {
+		wrapper = &GitHubClientWrapper{Client: github.NewClient(httpClient)}
+		wrapper.BaseURL = f.baseURL
+	} else {
+		wrapper, err = NewGitHubClientForHost(host, httpClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if f.token != "" {
+		wrapper.Client = wrapper.WithAuthToken(f.token)
+	}
+
+	if f.userAgent != "" {
+		wrapper.UserAgent = f.userAgent
+	}
+
+	return wrapper, nil
+}
+
+// apiHTTPClient returns the HTTP client used for API requests, wrapping
+// its transport in a RetryTransport if WithRetry was used.
+func (f *Fetcher) apiHTTPClient() *http.Client {
+	if f.httpClient == nil && f.retryPolicy == nil {
+		return newRetryHTTPClient()
+	}
+
+	httpClient := &http.Client{}
+	if f.httpClient != nil {
+		clone := *f.httpClient
+		httpClient = &clone
+	}
+
+	if f.retryPolicy != nil {
+		httpClient.Transport = NewRetryTransport(httpClient.Transport, *f.retryPolicy)
+	}
+
+	return httpClient
+}
+
+// downloadHTTPClient returns the HTTP client used to download diff URLs.
+func (f *Fetcher) downloadHTTPClient() *http.Client {
+	if f.httpClient == nil && f.retryPolicy == nil {
+		return diffHTTPClient
+	}
+
+	return f.apiHTTPClient()
+}
+
+// responseContentLength returns the Content-Length of a response, or -1 if
+// it is unknown.
+func responseContentLength(resp *github.Response) int64 {
+	if resp == nil || resp.Response == nil {
+		return -1
+	}
+
+	return resp.ContentLength
+}
+
+// limitDiffBody enforces a size limit on a diff body. A body whose
+// Content-Length already exceeds the limit is closed and rejected up front;
+// otherwise the limit is enforced while the body is read, since the length
+// of a compressed or chunked response is not known in advance.
+func limitDiffBody(body io.ReadCloser, contentLength, maxBytes int64) (io.ReadCloser, error) {
+	if maxBytes <= 0 {
+		return body, nil
+	}
+
+	if contentLength > maxBytes {
+		closeBody(body)
+
+		return nil, &DiffSizeError{Limit: maxBytes, Size: contentLength}
+	}
+
+	return &maxBytesReader{ReadCloser: body, limit: maxBytes, remaining: maxBytes}, nil
+}
+
+// maxBytesReader wraps a diff body, failing with a *DiffSizeError once
+// more than limit bytes have been read.
+type maxBytesReader struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+// Read reads from the wrapped body, returning an error instead of the
+// bytes past the limit.
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	if int64(n) > r.remaining {
+		n = int(r.remaining)
+		r.remaining = 0
+
+		return n, &DiffSizeError{Limit: r.limit, Size: -1}
+	}
+
+	r.remaining -= int64(n)
+
+	return n, err
+}
@@ -0,0 +1,182 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetcher_Options(t *testing.T) {
+	var attempts int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" || r.Header.Get("User-Agent") != "review-bot/1.0" {
+			http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+
+			return
+		}
+
+		attempts++
+		if attempts == 1 {
+			http.Error(w, `{"message": "Server Error"}`, http.StatusBadGateway)
+
+			return
+		}
+
+		switch r.Header.Get("Accept") {
+		case "application/vnd.github.v3.diff":
+			_, _ = w.Write([]byte("mock diff"))
+		case "application/vnd.github.v3.patch":
+			_, _ = w.Write([]byte("mock patch"))
+		default:
+			_, _ = w.Write([]byte(`{"number": 123, "title": "Mock pull request"}`))
+		}
+	}))
+	defer testServer.Close()
+
+	fetcher, err := NewFetcher(
+		WithBaseURL(testServer.URL+"/api/v3"),
+		WithHTTPClient(&http.Client{Timeout: 5 * time.Second}),
+		WithToken("secret-token"),
+		WithUserAgent("review-bot/1.0"),
+		WithRetry(RetryPolicy{MaxAttempts: 2}),
+	)
+	require.NoError(t, err)
+
+	prURL := &PullRequestURL{Host: "github.com", Owner: "user", Repo: "repo", PRNumber: 123}
+
+	diff, err := fetcher.Diff(context.Background(), prURL)
+	require.NoError(t, err)
+	require.Equal(t, "mock diff", diff)
+	require.Equal(t, 2, attempts)
+
+	patch, err := fetcher.Patch(context.Background(), prURL)
+	require.NoError(t, err)
+	require.Equal(t, "mock patch", patch)
+
+	pullRequest, err := fetcher.PullRequest(context.Background(), prURL)
+	require.NoError(t, err)
+	require.Equal(t, "Mock pull request", pullRequest.GetTitle())
+}
+
+func TestFetcher_WithMaxBytes(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+	}))
+	defer testServer.Close()
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+
+	fetcher, err := NewFetcher(WithBaseURL(testServer.URL), WithMaxBytes(99))
+	require.NoError(t, err)
+
+	_, err = fetcher.Diff(context.Background(), prURL)
+	require.ErrorIs(t, err, ErrDiffTooLarge)
+
+	fetcher, err = NewFetcher(WithBaseURL(testServer.URL), WithMaxBytes(100))
+	require.NoError(t, err)
+
+	diff, err := fetcher.Diff(context.Background(), prURL)
+	require.NoError(t, err)
+	require.Len(t, diff, 100)
+}
+
+func TestFetcher_WithGitHubClient(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("mock " + r.URL.Path))
+	}))
+	defer testServer.Close()
+
+	mockClient := &clientWithoutRaw{
+		mock: &MockGitClient{
+			MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+				return &github.PullRequest{
+					DiffURL:  github.String(testServer.URL + "/123.diff"),
+					PatchURL: github.String(testServer.URL + "/123.patch"),
+				}, nil, nil
+			},
+		},
+	}
+
+	fetcher, err := NewFetcher(WithGitHubClient(mockClient), WithHTTPClient(testServer.Client()))
+	require.NoError(t, err)
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+
+	diff, err := fetcher.Diff(context.Background(), prURL)
+	require.NoError(t, err)
+	require.Equal(t, "mock /123.diff", diff)
+
+	patch, err := fetcher.Patch(context.Background(), prURL)
+	require.NoError(t, err)
+	require.Equal(t, "mock /123.patch", patch)
+}
+
+func TestNewFetcher_InvalidBaseURL(t *testing.T) {
+	_, err := NewFetcher(WithBaseURL("ftp://example.com"))
+	require.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = NewFetcher(WithBaseURL("http://[::1"))
+	require.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = NewFetcher(WithGitLabBaseURL("ftp://example.com"))
+	require.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = NewFetcher(WithBitbucketBaseURL("http://[::1"))
+	require.ErrorIs(t, err, ErrInvalidURL)
+}
+
+// clientWithoutRaw hides the GetRaw method of a MockGitClient, so that the
+// diff is downloaded from the diff URL of the pull request.
+type clientWithoutRaw struct {
+	mock *MockGitClient
+}
+
+func (c *clientWithoutRaw) Get(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+	return c.mock.Get(ctx, owner, repo, number)
+}
+
+func TestFetcher_WithMaxBytesContentLength(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte(strings.Repeat("x", 100)))
+
+			return
+		}
+
+		for i := 0; i < 10; i++ {
+			_, _ = w.Write([]byte(strings.Repeat("x", 10)))
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer testServer.Close()
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+
+	fetcher, err := NewFetcher(WithBaseURL(testServer.URL), WithMaxBytes(50))
+	require.NoError(t, err)
+
+	_, err = fetcher.DiffReader(context.Background(), prURL)
+
+	var sizeErr *DiffSizeError
+	require.ErrorAs(t, err, &sizeErr)
+	require.Equal(t, &DiffSizeError{Limit: 50, Size: 100}, sizeErr)
+
+	fetcher, err = NewFetcher(WithGitHubClient(&MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{DiffURL: github.String(testServer.URL + "/123.diff?chunked=1")}, nil, nil
+		},
+	}), WithMaxBytes(50))
+	require.NoError(t, err)
+
+	_, err = fetcher.Diff(context.Background(), prURL)
+	require.ErrorAs(t, err, &sizeErr)
+	require.Equal(t, &DiffSizeError{Limit: 50, Size: -1}, sizeErr)
+}
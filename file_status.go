@@ -0,0 +1,120 @@
+package github
+
+import (
+	"strconv"
+	"strings"
+)
+
+// FileStatus describes what happened to a file in a diff, as indicated by
+// the extended header lines that follow the "diff --git" line.
+type FileStatus int
+
+const (
+	// StatusModified is a file whose contents changed in place. It is the
+	// default when no extended header indicates otherwise.
+	StatusModified FileStatus = iota
+
+	// StatusAdded is a newly created file ("new file mode").
+	StatusAdded
+
+	// StatusDeleted is a removed file ("deleted file mode").
+	StatusDeleted
+
+	// StatusRenamed is a file moved to a new path ("rename from/to"),
+	// possibly with changes to its contents.
+	StatusRenamed
+
+	// StatusCopied is a file copied to a new path ("copy from/to"),
+	// possibly with changes to its contents.
+	StatusCopied
+
+	// StatusModeChanged is a file whose permissions changed ("old mode/new
+	// mode"). Its contents may have changed as well.
+	StatusModeChanged
+)
+
+// String returns a human-readable name for the file status.
+func (s FileStatus) String() string {
+	switch s {
+	case StatusModified:
+		return "modified"
+	case StatusAdded:
+		return "added"
+	case StatusDeleted:
+		return "deleted"
+	case StatusRenamed:
+		return "renamed"
+	case StatusCopied:
+		return "copied"
+	case StatusModeChanged:
+		return "mode changed"
+	default:
+		return "unknown"
+	}
+}
+
+// parseExtendedHeader applies a single git extended header line, such as
+// "new file mode 100644" or "similarity index 90%", to the given GitDiff.
+// It returns false if the line is not an extended header, which marks the
+// end of the header section of a file diff.
+func parseExtendedHeader(line string, gitDiff *GitDiff) bool {
+	switch {
+	case strings.HasPrefix(line, "new file mode "):
+		gitDiff.Status = StatusAdded
+		gitDiff.NewMode = strings.TrimPrefix(line, "new file mode ")
+	case strings.HasPrefix(line, "deleted file mode "):
+		gitDiff.Status = StatusDeleted
+		gitDiff.OldMode = strings.TrimPrefix(line, "deleted file mode ")
+	case strings.HasPrefix(line, "old mode "):
+		gitDiff.OldMode = strings.TrimPrefix(line, "old mode ")
+		if gitDiff.Status == StatusModified {
+			gitDiff.Status = StatusModeChanged
+		}
+	case strings.HasPrefix(line, "new mode "):
+		gitDiff.NewMode = strings.TrimPrefix(line, "new mode ")
+		if gitDiff.Status == StatusModified {
+			gitDiff.Status = StatusModeChanged
+		}
+	case strings.HasPrefix(line, "rename from "), strings.HasPrefix(line, "rename to "):
+		gitDiff.Status = StatusRenamed
+	case strings.HasPrefix(line, "copy from "), strings.HasPrefix(line, "copy to "):
+		gitDiff.Status = StatusCopied
+	case strings.HasPrefix(line, "similarity index "):
+		gitDiff.Similarity = parsePercentage(strings.TrimPrefix(line, "similarity index "))
+	case strings.HasPrefix(line, "dissimilarity index "):
+		gitDiff.Similarity = 100 - parsePercentage(strings.TrimPrefix(line, "dissimilarity index "))
+	default:
+		return false
+	}
+
+	return true
+}
+
+// parseIndexModes fills in the file modes from the trailing mode of an
+// index line ("abc123..def456 100644"), which git only includes when the
+// mode is the same before and after the change.
+func parseIndexModes(index string, gitDiff *GitDiff) {
+	fields := strings.Fields(index)
+	if len(fields) != 2 {
+		return
+	}
+
+	if gitDiff.OldMode == "" && gitDiff.Status != StatusAdded {
+		gitDiff.OldMode = fields[1]
+	}
+
+	if gitDiff.NewMode == "" && gitDiff.Status != StatusDeleted {
+		gitDiff.NewMode = fields[1]
+	}
+}
+
+// parsePercentage converts a value such as "90%" to an integer, returning
+// 0 if it cannot be parsed.
+func parsePercentage(value string) int {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
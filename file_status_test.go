@@ -0,0 +1,197 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitDiffFileString_Status(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		status     FileStatus
+		oldMode    string
+		newMode    string
+		similarity int
+		contents   string
+	}{
+		{
+			name: "Added file",
+			input: `diff --git a/new.go b/new.go
+new file mode 100644
+index 0000000..e69de29
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1 @@
++package main`,
+			status:   StatusAdded,
+			newMode:  "100644",
+			contents: "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+package main",
+		},
+		{
+			name: "Deleted file",
+			input: `diff --git a/old.go b/old.go
+deleted file mode 100755
+index e69de29..0000000
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package main`,
+			status:   StatusDeleted,
+			oldMode:  "100755",
+			contents: "--- a/old.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-package main",
+		},
+		{
+			name: "Renamed file with changes",
+			input: `diff --git a/a.go b/b.go
+similarity index 90%
+rename from a.go
+rename to b.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/b.go
+@@ -1 +1 @@
+-package a
++package b`,
+			status:     StatusRenamed,
+			oldMode:    "100644",
+			newMode:    "100644",
+			similarity: 90,
+			contents:   "--- a/a.go\n+++ b/b.go\n@@ -1 +1 @@\n-package a\n+package b",
+		},
+		{
+			name: "Copied file with changes",
+			input: `diff --git a/a.go b/c.go
+similarity index 75%
+copy from a.go
+copy to c.go
+index 1111111..3333333 100644
+--- a/a.go
++++ b/c.go
+@@ -1 +1 @@
+-package a
++package c`,
+			status:     StatusCopied,
+			oldMode:    "100644",
+			newMode:    "100644",
+			similarity: 75,
+			contents:   "--- a/a.go\n+++ b/c.go\n@@ -1 +1 @@\n-package a\n+package c",
+		},
+		{
+			name: "Mode changed with content changes",
+			input: `diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+index 1111111..2222222
+--- a/run.sh
++++ b/run.sh
+@@ -1 +1 @@
+-echo a
++echo b`,
+			status:   StatusModeChanged,
+			oldMode:  "100644",
+			newMode:  "100755",
+			contents: "--- a/run.sh\n+++ b/run.sh\n@@ -1 +1 @@\n-echo a\n+echo b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGitDiffFileString(tt.input, false, ParseOptions{})
+
+			require.NoError(t, err)
+			require.Equal(t, tt.status, got.Status)
+			require.Equal(t, tt.oldMode, got.OldMode)
+			require.Equal(t, tt.newMode, got.NewMode)
+			require.Equal(t, tt.similarity, got.Similarity)
+			require.Equal(t, tt.contents, got.DiffContents)
+		})
+	}
+}
+
+func TestParseExtendedHeader_NotAHeader(t *testing.T) {
+	gitDiff := &GitDiff{}
+
+	require.False(t, parseExtendedHeader("--- a/file.go", gitDiff))
+	require.Equal(t, &GitDiff{}, gitDiff)
+}
+
+func TestFileStatusString(t *testing.T) {
+	require.Equal(t, "modified", StatusModified.String())
+	require.Equal(t, "added", StatusAdded.String())
+	require.Equal(t, "deleted", StatusDeleted.String())
+	require.Equal(t, "renamed", StatusRenamed.String())
+	require.Equal(t, "copied", StatusCopied.String())
+	require.Equal(t, "mode changed", StatusModeChanged.String())
+}
+
+func TestParseGitDiffFileString_NoIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *GitDiff
+	}{
+		{
+			name: "Pure rename",
+			input: `diff --git a/old/name.go b/new/name.go
+similarity index 100%
+rename from old/name.go
+rename to new/name.go`,
+			want: &GitDiff{
+				FilePathOld: "old/name.go",
+				FilePathNew: "new/name.go",
+				Status:      StatusRenamed,
+				Similarity:  100,
+			},
+		},
+		{
+			name: "Pure mode change",
+			input: `diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755`,
+			want: &GitDiff{
+				FilePathOld: "run.sh",
+				FilePathNew: "run.sh",
+				Status:      StatusModeChanged,
+				OldMode:     "100644",
+				NewMode:     "100755",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGitDiffFileString(tt.input, false, ParseOptions{})
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseGitDiff_KeepsRenamesAndModeChanges(t *testing.T) {
+	diff := `diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+diff --git a/run.sh b/run.sh
+old mode 100644
+new mode 100755
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package a
++package main`
+
+	result := ParseGitDiff(diff, nil)
+
+	require.Len(t, result, 3)
+	require.Equal(t, StatusRenamed, result[0].Status)
+	require.Empty(t, result[0].Index)
+	require.Equal(t, StatusModeChanged, result[1].Status)
+	require.Empty(t, result[1].Index)
+	require.Equal(t, StatusModified, result[2].Status)
+}
@@ -2,6 +2,11 @@ package github
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 
 	"github.com/google/go-github/v57/github"
 )
@@ -41,13 +46,194 @@ func (c *GitHubClientWrapper) Get(
 	return c.PullRequests.Get(ctx, owner, repo, number)
 }
 
-// MockGitClient is a mock implementation of the GitHubClientInterface, intended for
-// use in unit tests. It allows for setting custom behavior for the Get method, enabling
+// NewGitHubClientForHost creates a GitHubClientWrapper for the GitHub instance at the given host.
+// For github.com, or an empty host, the client uses the public API at api.github.com. For any
+// other host, the client is configured for the GitHub Enterprise Server API at
+// https://[host]/api/v3/.
+//
+// Parameters:
+//   - host: The host name of the GitHub instance, such as "github.com" or "ghe.example.com".
+//   - httpClient: The HTTP client used for API requests, or nil to use a client that retries
+//     failed requests with DefaultRetryPolicy. Pass an authenticating client to access private
+//     repositories, and wrap its transport in a RetryTransport to keep retrying requests.
+//
+// Returns:
+//   - A pointer to a GitHubClientWrapper configured for the host.
+//   - An error if the Enterprise Server URLs cannot be constructed from the host.
+//
+// Example:
+//
+//	client, err := NewGitHubClientForHost("ghe.example.com", nil)
+//	if err != nil {
+//	  // Handle error
+//	}
+//	diff, err := GetPullRequestWithClient(ctx, prURL, client)
+func NewGitHubClientForHost(host string, httpClient *http.Client) (*GitHubClientWrapper, error) {
+	if httpClient == nil {
+		httpClient = newRetryHTTPClient()
+	}
+
+	client := github.NewClient(httpClient)
+
+	if isPublicGitHubHost(host) {
+		return &GitHubClientWrapper{Client: client}, nil
+	}
+
+	client, err := client.WithEnterpriseURLs(
+		fmt.Sprintf("https://%s/api/v3/", host),
+		fmt.Sprintf("https://%s/api/uploads/", host),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GitHubClientWrapper{Client: client}, nil
+}
+
+// NewGitHubClientForPullRequest creates a GitHubClientWrapper for the GitHub instance hosting
+// the given pull request, as returned by ParsePullRequestURL. It is equivalent to calling
+// NewGitHubClientForHost with the pull request's Host.
+func NewGitHubClientForPullRequest(pr *PullRequestURL, httpClient *http.Client) (*GitHubClientWrapper, error) {
+	return NewGitHubClientForHost(pr.Host, httpClient)
+}
+
+// isPublicGitHubHost reports whether host refers to github.com rather than
+// a GitHub Enterprise Server instance.
+func isPublicGitHubHost(host string) bool {
+	switch strings.ToLower(host) {
+	case "", "github.com", "www.github.com", "api.github.com":
+		return true
+	default:
+		return false
+	}
+}
+
+// GitHubRawClientInterface extends GitHubClientInterface with the ability to download
+// the raw diff or patch of a pull request. When the client passed to functions such as
+// GetPullRequestWithClient implements this interface, the diff is downloaded with GetRaw
+// instead of through the unauthenticated diff URL in the pull request details, which also
+// allows the download to be mocked in tests.
+type GitHubRawClientInterface interface {
+	GitHubClientInterface
+
+	// GetRaw retrieves the raw diff or patch of a specific pull request, as selected by
+	// opts.Type, based on the provided owner, repository name, and pull request number.
+	// The caller must close the returned io.ReadCloser.
+	GetRaw(
+		ctx context.Context,
+		owner string,
+		repo string,
+		number int,
+		opts github.RawOptions,
+	) (io.ReadCloser, *github.Response, error)
+}
+
+// GetRaw streams the raw diff or patch of a pull request from the GitHub API. It requests
+// the pull request with the "application/vnd.github.v3.diff" or "application/vnd.github.v3.patch"
+// media type, so the download uses the same authenticated transport as every other call made
+// with the wrapped client. This makes it work for private repositories, unlike the diff URL
+// returned in the pull request details. The caller must close the returned io.ReadCloser.
+func (c *GitHubClientWrapper) GetRaw(
+	ctx context.Context,
+	owner string,
+	repo string,
+	number int,
+	opts github.RawOptions,
+) (io.ReadCloser, *github.Response, error) {
+	return c.getRaw(ctx, fmt.Sprintf("repos/%v/%v/pulls/%d", owner, repo, number), opts)
+}
+
+// getRaw requests an API path with the diff or patch media type selected
+// by opts and returns the unread response body.
+func (c *GitHubClientWrapper) getRaw(ctx context.Context, path string, opts github.RawOptions) (io.ReadCloser, *github.Response, error) {
+	var mediaType string
+
+	switch opts.Type {
+	case github.Diff:
+		mediaType = "application/vnd.github.v3.diff"
+	case github.Patch:
+		mediaType = "application/vnd.github.v3.patch"
+	default:
+		return nil, nil, errors.New("unsupported raw type")
+	}
+
+	req, err := c.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Header.Set("Accept", mediaType)
+
+	resp, err := c.BareDo(ctx, req)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return resp.Body, resp, nil
+}
+
+// MockGitClient is a mock implementation of the GitHubClientInterface and its extensions,
+// such as GitHubRawClientInterface, intended for use in unit tests. It allows for setting
+// custom behavior for each of their methods through the matching MockXxx field, enabling
 // developers to test their code without making actual API calls to GitHub.
 type MockGitClient struct {
 	// MockGet is a function that simulates the Get method of GitHubClientInterface.
 	// This function can be customized in test scenarios to return specific values or errors.
 	MockGet func(ctx context.Context, owner string, repo string, number int) (*github.PullRequest, *github.Response, error)
+
+	// MockGetRaw is a function that simulates the GetRaw method of GitHubRawClientInterface.
+	// Setting it allows functions such as GetPullRequestWithClient to run end to end without
+	// network access.
+	MockGetRaw func(
+		ctx context.Context,
+		owner string,
+		repo string,
+		number int,
+		opts github.RawOptions,
+	) (io.ReadCloser, *github.Response, error)
+
+	// MockListFiles is a function that simulates the ListFiles method of
+	// GitHubFilesClientInterface, for testing the fallback used for pull requests whose
+	// diff is too large.
+	MockListFiles func(
+		ctx context.Context,
+		owner string,
+		repo string,
+		number int,
+		opts *github.ListOptions,
+	) ([]*github.CommitFile, *github.Response, error)
+
+	// MockCompareRaw is a function that simulates the CompareRaw method of
+	// GitHubCompareClientInterface.
+	MockCompareRaw func(
+		ctx context.Context,
+		owner string,
+		repo string,
+		base string,
+		head string,
+		opts github.RawOptions,
+	) (io.ReadCloser, *github.Response, error)
+
+	// MockCommitRaw is a function that simulates the CommitRaw method of
+	// GitHubCommitClientInterface.
+	MockCommitRaw func(
+		ctx context.Context,
+		owner string,
+		repo string,
+		sha string,
+		opts github.RawOptions,
+	) (io.ReadCloser, *github.Response, error)
+
+	// MockCompareCommits is a function that simulates the CompareCommits method of
+	// GitHubCompareCommitsClientInterface.
+	MockCompareCommits func(
+		ctx context.Context,
+		owner string,
+		repo string,
+		base string,
+		head string,
+		opts *github.ListOptions,
+	) (*github.CommitsComparison, *github.Response, error)
 }
 
 // Get calls the mock implementation of the Get method. If MockGet is set to a custom function,
@@ -59,3 +245,49 @@ func (m *MockGitClient) Get(ctx context.Context, owner string, repo string, numb
 	}
 	return nil, nil, nil
 }
+
+// GetRaw calls the mock implementation of the GetRaw method. If MockGetRaw is set to a
+// custom function, that function is executed and its result returned. If MockGetRaw is not
+// set, the method calls Get and downloads the diff URL of the returned pull request, which
+// is how the diff is fetched for clients that do not implement GitHubRawClientInterface. An
+// error is returned if the pull request has no such URL.
+func (m *MockGitClient) GetRaw(
+	ctx context.Context,
+	owner string,
+	repo string,
+	number int,
+	opts github.RawOptions,
+) (io.ReadCloser, *github.Response, error) {
+	if m.MockGetRaw != nil {
+		return m.MockGetRaw(ctx, owner, repo, number, opts)
+	}
+
+	pullRequest, resp, err := m.Get(ctx, owner, repo, number)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	rawURL := pullRequest.GetDiffURL()
+	if opts.Type == github.Patch {
+		rawURL = pullRequest.GetPatchURL()
+	}
+
+	// Without a URL, such as when MockGet is not set either, there is
+	// nothing to download.
+	if rawURL == "" {
+		return nil, resp, errors.New("MockGitClient: the pull request returned by Get has no URL to download")
+	}
+
+	httpResp, err := openDiffURLWithClient(ctx, diffHTTPClient, rawURL)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return httpResp.Body, resp, nil
+}
+
+// errMockNotSet returns the error a MockGitClient method returns when its
+// mock function, named by field, is not set.
+func errMockNotSet(field string) error {
+	return fmt.Errorf("MockGitClient: %s is not set", field)
+}
@@ -0,0 +1,313 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestGitHubClient returns a GitHubClientWrapper whose API calls are
+// sent to the given test server.
+func newTestGitHubClient(t *testing.T, server *httptest.Server, token string) *GitHubClientWrapper {
+	t.Helper()
+
+	client := github.NewClient(nil)
+	if token != "" {
+		client = client.WithAuthToken(token)
+	}
+
+	baseURL, err := url.Parse(server.URL + "/")
+	require.NoError(t, err)
+
+	client.BaseURL = baseURL
+
+	return &GitHubClientWrapper{Client: client}
+}
+
+func TestGetPullRequestWithClient_AuthenticatedDiff(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+
+			return
+		}
+
+		if r.URL.Path != "/repos/user/repo/pulls/123" || r.Header.Get("Accept") != "application/vnd.github.v3.diff" {
+			http.Error(w, `{"message": "Bad Request"}`, http.StatusBadRequest)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("mock private diff"))
+	}))
+	defer testServer.Close()
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+
+	diff, err := GetPullRequestWithClient(context.Background(), prURL, newTestGitHubClient(t, testServer, "secret-token"))
+	require.NoError(t, err)
+	require.Equal(t, "mock private diff", diff)
+
+	diff, err = GetPullRequestWithClient(context.Background(), prURL, newTestGitHubClient(t, testServer, ""))
+	require.Error(t, err)
+	require.Empty(t, diff)
+}
+
+func TestGetPullRequestWithClient_RetriesWrapper(t *testing.T) {
+	var attempts, failures int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+
+			return
+		}
+
+		attempts++
+		if attempts <= failures {
+			http.Error(w, `{"message": "Server Error"}`, http.StatusBadGateway)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("mock diff"))
+	}))
+	defer testServer.Close()
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+
+	baseURL := newTestGitHubClient(t, testServer, "").BaseURL
+
+	// newClient returns a wrapper whose transport, if given, is hidden under
+	// the authenticating transport added by WithAuthToken.
+	newClient := func(transport http.RoundTripper) *GitHubClientWrapper {
+		client := github.NewClient(&http.Client{Transport: transport}).WithAuthToken("secret-token")
+		client.BaseURL = baseURL
+
+		return &GitHubClientWrapper{Client: client}
+	}
+
+	attempts, failures = 0, 1
+
+	fetcher, err := NewFetcher(WithGitHubClient(newClient(nil)), WithRetry(RetryPolicy{MaxAttempts: 3}))
+	require.NoError(t, err)
+
+	diff, err := fetcher.Diff(context.Background(), prURL)
+	require.NoError(t, err)
+	require.Equal(t, "mock diff", diff)
+	require.Equal(t, 2, attempts)
+
+	attempts, failures = 0, 100
+
+	diff, err = GetPullRequestWithClient(context.Background(), prURL, newClient(NewRetryTransport(nil, RetryPolicy{MaxAttempts: 3})))
+	require.Error(t, err)
+	require.Empty(t, diff)
+	require.Equal(t, 3, attempts)
+
+	attempts = 0
+
+	fetcher, err = NewFetcher(
+		WithGitHubClient(newClient(NewRetryTransport(nil, RetryPolicy{MaxAttempts: 3}))),
+		WithRetry(RetryPolicy{MaxAttempts: 2}),
+	)
+	require.NoError(t, err)
+
+	_, err = fetcher.Diff(context.Background(), prURL)
+	require.Error(t, err)
+	require.Equal(t, 2, attempts)
+
+	attempts = 0
+
+	fetcher, err = NewFetcher(WithGitHubClient(newClient(nil)), WithRetry(RetryPolicy{MaxAttempts: 1}))
+	require.NoError(t, err)
+
+	_, err = fetcher.Diff(context.Background(), prURL)
+
+	var statusErr *HTTPStatusError
+	require.ErrorAs(t, err, &statusErr)
+	require.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+	require.Equal(t, 1, attempts)
+}
+
+func TestRetryingGitHubClient(t *testing.T) {
+	retrying, err := NewGitHubClientForHost("ghe.example.com", nil)
+	require.NoError(t, err)
+	require.Same(t, retrying, retryingGitHubClient(retrying, nil))
+
+	policy := RetryPolicy{MaxAttempts: 2}
+	replaced := retryingGitHubClient(retrying, &policy)
+	require.NotSame(t, retrying, replaced)
+	require.Equal(t, retrying.BaseURL, replaced.BaseURL)
+	require.Equal(t, retrying.UploadURL, replaced.UploadURL)
+
+	transport, ok := replaced.Client.Client().Transport.(*RetryTransport)
+	require.True(t, ok)
+	require.Equal(t, policy, transport.Policy)
+	require.Nil(t, transport.Base)
+
+	wrapped := retryingGitHubClient(&GitHubClientWrapper{Client: github.NewClient(nil)}, nil)
+
+	transport, ok = wrapped.Client.Client().Transport.(*RetryTransport)
+	require.True(t, ok)
+	require.Equal(t, DefaultRetryPolicy(), transport.Policy)
+}
+
+func TestGitHubClientWrapper_GetRawPatch(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept") != "application/vnd.github.v3.patch" {
+			http.Error(w, `{"message": "Bad Request"}`, http.StatusBadRequest)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("mock patch"))
+	}))
+	defer testServer.Close()
+
+	client := newTestGitHubClient(t, testServer, "")
+
+	body, _, err := client.GetRaw(context.Background(), "user", "repo", 123, github.RawOptions{Type: github.Patch})
+	require.NoError(t, err)
+	defer body.Close()
+
+	patch, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.Equal(t, "mock patch", string(patch))
+
+	_, _, err = client.GetRaw(context.Background(), "user", "repo", 123, github.RawOptions{})
+	require.Error(t, err)
+}
+
+func TestGetPullRequestWithClient_MockGetRaw(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockGetRaw: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			require.Equal(t, github.Diff, opts.Type)
+
+			return io.NopCloser(strings.NewReader("diff --git a/file1.go b/file1.go\nindex 1..2 100644\n")), nil, nil
+		},
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+	diff, err := GetPullRequestWithClient(context.Background(), prURL, mockClient)
+
+	require.NoError(t, err)
+
+	gitDiffs := ParseGitDiff(diff, nil)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "file1.go", gitDiffs[0].FilePathNew)
+}
+
+func TestMockGitClient_GetRawFallsBackToGet(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("mock " + r.URL.Path))
+	}))
+	defer testServer.Close()
+
+	mockClient := &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{
+				DiffURL:  github.String(testServer.URL + "/123.diff"),
+				PatchURL: github.String(testServer.URL + "/123.patch"),
+			}, nil, nil
+		},
+	}
+
+	for rawType, want := range map[github.RawType]string{github.Diff: "mock /123.diff", github.Patch: "mock /123.patch"} {
+		body, _, err := mockClient.GetRaw(context.Background(), "user", "repo", 123, github.RawOptions{Type: rawType})
+		require.NoError(t, err)
+
+		contents, err := io.ReadAll(body)
+		require.NoError(t, err)
+		require.NoError(t, body.Close())
+		require.Equal(t, want, string(contents))
+	}
+
+	diff, err := GetPullRequestWithClient(context.Background(), &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}, mockClient)
+	require.NoError(t, err)
+	require.Equal(t, "mock /123.diff", diff)
+}
+
+func TestMockGitClient_UnsetHooks(t *testing.T) {
+	ctx := context.Background()
+	mockClient := &MockGitClient{}
+
+	pullRequest, resp, err := mockClient.Get(ctx, "user", "repo", 123)
+	require.NoError(t, err)
+	require.Nil(t, pullRequest)
+	require.Nil(t, resp)
+
+	_, _, err = mockClient.GetRaw(ctx, "user", "repo", 123, github.RawOptions{Type: github.Diff})
+	require.EqualError(t, err, "MockGitClient: the pull request returned by Get has no URL to download")
+
+	_, _, err = mockClient.ListFiles(ctx, "user", "repo", 123, nil)
+	require.EqualError(t, err, "MockGitClient: MockListFiles is not set")
+
+	_, _, err = mockClient.CompareRaw(ctx, "user", "repo", "base", "head", github.RawOptions{})
+	require.EqualError(t, err, "MockGitClient: MockCompareRaw is not set")
+
+	_, _, err = mockClient.CommitRaw(ctx, "user", "repo", "sha", github.RawOptions{})
+	require.EqualError(t, err, "MockGitClient: MockCommitRaw is not set")
+
+	_, _, err = mockClient.CompareCommits(ctx, "user", "repo", "base", "head", nil)
+	require.EqualError(t, err, "MockGitClient: MockCompareCommits is not set")
+}
+
+func TestNewGitHubClientForHost(t *testing.T) {
+	tests := []struct {
+		host    string
+		baseURL string
+	}{
+		{host: "", baseURL: "https://api.github.com/"},
+		{host: "github.com", baseURL: "https://api.github.com/"},
+		{host: "ghe.example.com", baseURL: "https://ghe.example.com/api/v3/"},
+		{host: "ghe.example.com:8443", baseURL: "https://ghe.example.com:8443/api/v3/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			client, err := NewGitHubClientForHost(tt.host, nil)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.baseURL, client.BaseURL.String())
+		})
+	}
+}
+
+func TestGetPullRequestWithClient_Enterprise(t *testing.T) {
+	testServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/org/repo/pulls/7" {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("mock enterprise diff"))
+	}))
+	defer testServer.Close()
+
+	serverURL, err := url.Parse(testServer.URL)
+	require.NoError(t, err)
+
+	prURL, err := ParsePullRequestURL("https://" + serverURL.Host + "/org/repo/pull/7")
+	require.NoError(t, err)
+	require.Equal(t, serverURL.Host, prURL.Host)
+
+	client, err := NewGitHubClientForPullRequest(prURL, testServer.Client())
+	require.NoError(t, err)
+
+	diff, err := GetPullRequestWithClient(context.Background(), prURL, client)
+	require.NoError(t, err)
+	require.Equal(t, "mock enterprise diff", diff)
+}
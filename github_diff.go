@@ -1,21 +1,24 @@
 package github
 
 import (
-	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"path/filepath"
 	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/google/go-github/v57/github"
 )
 
 type PullRequestURL struct {
+	// Host is the host name of the GitHub instance the pull request lives
+	// on, such as "github.com" or the host of a GitHub Enterprise Server.
+	// An empty Host is treated as "github.com".
+	Host     string
 	Owner    string
 	Repo     string
 	PRNumber int
@@ -24,15 +27,36 @@ type PullRequestURL struct {
 type GitDiff struct {
 	// FilePathOld represents the old file path in the diff, typically
 	// indicated by a line starting with "---". This is the file path
-	// before the changes were made.
+	// before the changes were made. Quoted paths are unquoted, and the
+	// "a/" prefix is stripped unless ParseOptions.KeepPathPrefixes is set.
 	FilePathOld string
 
 	// FilePathNew represents the new file path in the diff, typically
 	// indicated by a line starting with "+++ ". This is the file path
 	// after the changes were made. In most cases, it is the same as
-	// FilePathOld unless the file was renamed or moved.
+	// FilePathOld unless the file was renamed or moved. Quoted paths are
+	// unquoted, and the "b/" prefix is stripped unless
+	// ParseOptions.KeepPathPrefixes is set.
 	FilePathNew string
 
+	// Status describes what happened to the file, such as whether it was
+	// added, deleted or renamed. It is parsed from the extended header
+	// lines that follow the "diff --git" line.
+	Status FileStatus
+
+	// OldMode is the file mode before the change (e.g. "100644"). It is
+	// empty for added files.
+	OldMode string
+
+	// NewMode is the file mode after the change (e.g. "100755"). It is
+	// empty for deleted files.
+	NewMode string
+
+	// Similarity is the similarity percentage reported for renamed and
+	// copied files, or 100 minus the dissimilarity percentage reported
+	// for rewritten files. It is 0 when git did not report either.
+	Similarity int
+
 	// Index is a string that usually contains the hash values before
 	// and after the changes, along with some additional metadata.
 	// This line typically starts with "index" in the diff output.
@@ -42,28 +66,73 @@ type GitDiff struct {
 	// of the struct includes the changes made to the file, typically
 	// represented by lines starting with "+" (additions) or "-"
 	// (deletions). It includes all the lines that show the modifications
-	// to the file.
+	// to the file, but not the extended header lines parsed into Status,
+	// OldMode, NewMode and Similarity.
 	DiffContents string
+
+	// Hunks contains the structured form of DiffContents, with one entry
+	// per "@@ -a,b +c,d @@" section and the old and new line numbers of
+	// every line. It is empty for diffs without hunks.
+	Hunks []*Hunk
+
+	// IsBinary is true if git reported the file as binary, either with a
+	// "Binary files ... differ" line or a "GIT binary patch" section.
+	IsBinary bool
+
+	// BinaryPatch contains the decoded "GIT binary patch" section of the
+	// diff, which can be used to recover the new file contents. It is nil
+	// for text files and for binary files diffed without --binary.
+	BinaryPatch *BinaryPatch
+
+	// Truncated is true if DiffContents and Hunks do not contain the
+	// complete diff of the file. This happens when the diff was cut short
+	// by ParseOptions.MaxFileBytes, or rebuilt from GitHub's per-file API,
+	// which shortens or omits the patch of large files.
+	Truncated bool
+
+	// CommitFile contains the data GitHub's per-file API returned for the
+	// file, such as its addition and deletion counts, blob URL and SHA. It
+	// is only set by functions that list the files of a pull request, such
+	// as GetPullRequestDiff, and is nil otherwise.
+	CommitFile *github.CommitFile
 }
 
-// ParsePullRequestURL parses a GitHub pull request URL and returns the owner, repository,
-// and pull request number. The function expects a standard GitHub pull request URL format.
-// It splits the URL into segments and extracts the relevant information.
-//
-// The expected URL format is: https://github.com/[owner]/[repo]/pull/[prNumber]
-// where [owner] is the GitHub username or organization name, [repo] is the repository name,
-// and [prNumber] is the pull request number.
+// ParsePullRequestURL parses a GitHub pull request URL and returns the host, owner, repository,
+// and pull request number. The URL is parsed with net/url, and the host and path shape are
+// validated before the relevant information is extracted.
+//
+// The canonical URL format is: https://[host]/[owner]/[repo]/pull/[prNumber]
+// where [host] is github.com or the host of a GitHub Enterprise Server, [owner] is the GitHub
+// username or organization name, [repo] is the repository name, and [prNumber] is the pull
+// request number. The following variations, as copied from a browser or API response, are
+// also accepted:
+//   - The /files, /commits and /commits/<sha> sub-pages of the pull request, and the
+//     /pull/12.diff and /pull/12.patch forms. Other sub-pages are rejected.
+//   - Trailing slashes, query strings and fragments such as #discussion_r123.
+//   - The /pulls/12 form, including API URLs such as
+//     https://api.github.com/repos/[owner]/[repo]/pulls/12.
+//   - URLs without a scheme, such as github.com/[owner]/[repo]/pull/12.
+//   - References without a host, such as [owner]/[repo]/pull/12 or [owner]/[repo]#12,
+//     which refer to github.com.
+//   - The [host]/[owner]/[repo]#12 shorthand, such as ghe.example.com/[owner]/[repo]#12.
+//   - The arguments of gh CLI commands that name the repository, such as
+//     "12 -R [owner]/[repo]", "--repo=[host]/[owner]/[repo] #12" or
+//     "gh pr view 12 -R [owner]/[repo]".
+//
+// URLs on gitlab.com and bitbucket.org are rejected, since they are handled by
+// ParseMergeRequestURL and ParseBitbucketPullRequestURL. Use ParsePullRequestReference
+// to resolve references such as #12 that do not name the repository.
 //
 // Parameters:
-//   - pullRequestURL: A string representing the full URL of a GitHub pull request.
+//   - pullRequestURL: A string representing the URL of, or a reference to, a GitHub pull request.
 //
 // Returns:
-//   - A pointer to a PullRequestURL struct containing the extracted information (owner, repo, PRNumber).
-//   - An error if the URL format is invalid or if the pull request number cannot be converted to an integer.
+//   - A pointer to a PullRequestURL struct containing the extracted information (host, owner, repo, PRNumber).
+//   - An error if the URL is not a valid pull request URL or reference.
 //
 // Example:
 //
-//	prURL, err := ParsePullRequestURL("https://github.com/username/repository/pull/123")
+//	prURL, err := ParsePullRequestURL("https://github.com/username/repository/pull/123/files")
 //	if err != nil {
 //	  // Handle error
 //	}
@@ -72,24 +141,45 @@ type GitDiff struct {
 // This function is particularly useful for applications that need to process or respond to GitHub pull requests,
 // allowing them to easily extract and use the key components of a pull request URL.
 func ParsePullRequestURL(pullRequestURL string) (*PullRequestURL, error) {
-	parts := strings.Split(pullRequestURL, "/")
-
-	if len(parts) != 7 {
-		return nil, errors.New("invalid pull request URL")
+	pr, ok := parsePullRequestReference(pullRequestURL)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a pull request URL", ErrInvalidURL, pullRequestURL)
 	}
 
-	owner := parts[3]
-	repo := parts[4]
-	prNumber, err := strconv.Atoi(parts[6])
-	if err != nil {
-		return nil, err
+	return pr, nil
+}
+
+// ParsePullRequestReference parses a pull request reference in the same way as
+// ParsePullRequestURL, additionally resolving references that only contain the pull
+// request number, such as "12" or "#12", against a default repository. This matches how
+// the gh CLI resolves pull request arguments against the current repository.
+//
+// Parameters:
+//   - reference: A string representing a pull request URL, a reference accepted by
+//     ParsePullRequestURL, or a pull request number optionally prefixed with "#".
+//   - defaultRepo: The repository that numbers refer to, as "[owner]/[repo]",
+//     "[host]/[owner]/[repo]" or a repository URL. A repository given with -R or --repo
+//     in reference takes precedence.
+//
+// Returns:
+//   - A pointer to a PullRequestURL struct containing the extracted information.
+//   - An error matching ErrInvalidURL if the reference is not a valid pull request
+//     reference, or it is a number and defaultRepo is not a valid repository.
+//
+// Example:
+//
+//	prURL, err := ParsePullRequestReference("#123", "username/repository")
+//	if err != nil {
+//	  // Handle error
+//	}
+//	// prURL.PRNumber is 123
+func ParsePullRequestReference(reference string, defaultRepo string) (*PullRequestURL, error) {
+	pr, ok := parsePullRequestReferenceInRepo(reference, defaultRepo)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a pull request reference", ErrInvalidURL, reference)
 	}
 
-	return &PullRequestURL{
-		Owner:    owner,
-		Repo:     repo,
-		PRNumber: prNumber,
-	}, nil
+	return pr, nil
 }
 
 // Deprecated: Use GetPullRequestWithClient or GetPullRequestFromGithub instead.
@@ -97,9 +187,9 @@ func ParsePullRequestURL(pullRequestURL string) (*PullRequestURL, error) {
 //
 // GetPullRequest retrieves the contents of a pull request's Git diff from GitHub.
 // The function takes a context and a PullRequestURL struct, which contains the
-// information needed to identify the specific pull request. It uses the GitHub API
-// client to fetch the pull request and then calls getDiffContents to obtain the
-// raw diff data.
+// information needed to identify the specific pull request. It wraps the client in
+// a GitHubClientWrapper and calls GetPullRequestWithClient, so the diff is downloaded
+// with the client's authentication.
 //
 // Parameters:
 //   - ctx: A context.Context object, which allows for managing the lifecycle of
@@ -112,10 +202,8 @@ func ParsePullRequestURL(pullRequestURL string) (*PullRequestURL, error) {
 //   - An error if the pull request retrieval fails or if there is an issue obtaining
 //     the diff contents.
 //
-// The function first creates a new GitHub API client. It then uses this client to
-// fetch the pull request specified by the PullRequestURL struct. If the pull request
-// is successfully retrieved, the function extracts the URL to the pull request's diff
-// and uses getDiffContents to fetch the diff data.
+// The diff is requested from the pull requests API with the diff media type, using
+// the provided client's transport.
 //
 // Example:
 //
@@ -130,12 +218,7 @@ func ParsePullRequestURL(pullRequestURL string) (*PullRequestURL, error) {
 // and process the contents of pull requests from GitHub, such as in automated
 // code review tools, continuous integration systems, or other development workflows.
 func GetPullRequest(ctx context.Context, pr *PullRequestURL, client *github.Client) (string, error) {
-	pullRequest, _, err := client.PullRequests.Get(ctx, pr.Owner, pr.Repo, pr.PRNumber)
-	if err != nil {
-		return "", err
-	}
-
-	return getDiffContents(pullRequest.GetDiffURL())
+	return GetPullRequestWithClient(ctx, pr, &GitHubClientWrapper{Client: client})
 }
 
 // GetPullRequestWithClient retrieves the contents of a pull request's Git diff from GitHub using an injected client.
@@ -156,10 +239,26 @@ func GetPullRequest(ctx context.Context, pr *PullRequestURL, client *github.Clie
 //
 // The function uses the provided client to fetch the pull request specified by the PullRequestURL struct.
 // If the pull request is successfully retrieved, it extracts the URL of the pull request's diff and
-// calls getDiffContents to fetch the actual diff data. This approach allows for better testability and
+// downloads the actual diff data from it. This approach allows for better testability and
 // flexibility, as different client implementations can be used depending on the context (e.g., testing,
 // production).
 //
+// If the client also implements GitHubRawClientInterface, as GitHubClientWrapper does, the diff is instead
+// downloaded with its GetRaw method. GitHubClientWrapper requests it from the pull requests API with the
+// diff media type, so the download goes through the client's own authenticated transport, which is
+// required for private repositories.
+//
+// The raw diff is returned as GitHub renders it, so an error matching ErrDiffTooLarge is
+// returned for pull requests whose diff GitHub refuses to render. Only GetPullRequestGitDiffs,
+// GetPullRequestDiff and Fetcher.PullRequestGitDiffs fall back to the files of the pull
+// request in that case, since a diff rebuilt from them could not show which files GitHub
+// shortened or omitted.
+//
+// GetPullRequestWithClient is equivalent to calling Fetcher.Diff on a Fetcher created with
+// WithGitHubClient(client), so both the API call and the diff download are retried with
+// DefaultRetryPolicy when client is a GitHubClientWrapper. Use NewFetcher directly to
+// configure a size limit or a different retry policy.
+//
 // Example:
 //
 //	prURL := &PullRequestURL{Owner: "username", Repo: "repository", PRNumber: 123}
@@ -173,12 +272,50 @@ func GetPullRequest(ctx context.Context, pr *PullRequestURL, client *github.Clie
 // better control and testing, such as in automated code review tools, continuous integration systems,
 // or other applications that interact with GitHub pull requests programmatically.
 func GetPullRequestWithClient(ctx context.Context, pr *PullRequestURL, client GitHubClientInterface) (string, error) {
-	pullRequest, _, err := client.Get(ctx, pr.Owner, pr.Repo, pr.PRNumber)
+	fetcher, err := NewFetcher(WithGitHubClient(client))
 	if err != nil {
 		return "", err
 	}
 
-	return getDiffContents(pullRequest.GetDiffURL())
+	return fetcher.Diff(ctx, pr)
+}
+
+// GetPullRequestDiffReader opens the Git diff of a pull request for streaming. Unlike
+// GetPullRequestWithClient, the diff is not read into memory; the caller reads it from
+// the returned io.ReadCloser, for example with NewDiffReader, and must close it when done.
+//
+// Parameters:
+//   - ctx: A context.Context object, used for managing the lifecycle of the request. Canceling
+//     it aborts both the pull request lookup and the diff download, including while the
+//     caller is reading the returned body.
+//   - pr: A pointer to a PullRequestURL struct, containing the owner, repository, and pull request number.
+//   - client: An implementation of the GitHubClientInterface, used to look up the pull request.
+//
+// Returns:
+//   - An io.ReadCloser streaming the raw Git diff of the pull request.
+//   - An error if the pull request cannot be retrieved or the diff download fails.
+//
+// As with GetPullRequestWithClient, the diff is downloaded with GetRaw when the client implements
+// GitHubRawClientInterface, and there is no fallback to the files of the pull request when
+// GitHub refuses to render the diff.
+//
+// Example:
+//
+//	body, err := GetPullRequestDiffReader(ctx, prURL, injectedClient)
+//	if err != nil {
+//	  // Handle error
+//	}
+//	defer body.Close()
+//
+//	reader := NewDiffReader(body, ParseOptions{IgnoreList: ignoreList})
+//	// Call reader.Next() to process one file diff at a time
+func GetPullRequestDiffReader(ctx context.Context, pr *PullRequestURL, client GitHubClientInterface) (io.ReadCloser, error) {
+	fetcher, err := NewFetcher(WithGitHubClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.DiffReader(ctx, pr)
 }
 
 // GetPullRequestFromGithub retrieves the contents of a pull request's Git diff from GitHub using the default client.
@@ -196,9 +333,9 @@ func GetPullRequestWithClient(ctx context.Context, pr *PullRequestURL, client Gi
 //   - A string containing the raw contents of the Git diff for the specified pull request.
 //   - An error if there is a problem retrieving the pull request or obtaining the diff contents.
 //
-// The function creates a new instance of the default GitHub client and uses it to fetch the specified pull request.
-// After successfully retrieving the pull request, it extracts the URL of the pull request's diff and
-// utilizes getDiffContents to obtain the actual diff data.
+// The function creates a Fetcher with the default options, which uses the GitHub Enterprise Server
+// API when the pull request's Host is not github.com, and downloads the diff with its Diff method.
+// Use NewFetcher directly to configure authentication, timeouts or other options.
 //
 // Example:
 //
@@ -212,9 +349,12 @@ func GetPullRequestWithClient(ctx context.Context, pr *PullRequestURL, client Gi
 // This function is ideal for use cases where a simple, straightforward approach to interacting with GitHub pull
 // requests is needed, without the requirement for advanced configuration or dependency injection.
 func GetPullRequestFromGithub(ctx context.Context, pr *PullRequestURL) (string, error) {
-	client := github.NewClient(nil)
+	fetcher, err := NewFetcher()
+	if err != nil {
+		return "", err
+	}
 
-	return GetPullRequestWithClient(ctx, pr, &GitHubClientWrapper{Client: client})
+	return fetcher.Diff(ctx, pr)
 }
 
 // GetPullRequestWithDetails retrieves detailed information about a specific pull request from GitHub.
@@ -236,6 +376,9 @@ func GetPullRequestFromGithub(ctx context.Context, pr *PullRequestURL) (string,
 // owner, repo, and pull request number. It then returns the resulting github.PullRequest struct, which includes
 // comprehensive details about the pull request, or an error if the request fails.
 //
+// GetPullRequestWithDetails is equivalent to calling Fetcher.PullRequest on a Fetcher created with
+// WithGitHubClient(client), so the request is retried in the same way as by GetPullRequestWithClient.
+//
 // Example:
 //
 //	prDetails, err := GetPullRequestWithDetails(context.Background(), prURL, githubClient)
@@ -248,14 +391,14 @@ func GetPullRequestWithDetails(
 	pr *PullRequestURL,
 	client GitHubClientInterface) (*github.PullRequest, error) {
 
-	pullRequest, _, err := client.Get(ctx, pr.Owner, pr.Repo, pr.PRNumber)
+	fetcher, err := NewFetcher(WithGitHubClient(client))
 
 	if err != nil {
 
 		return nil, err
 	}
 
-	return pullRequest, nil
+	return fetcher.PullRequest(ctx, pr)
 }
 
 // ParseGitDiff takes a string representing a combined Git diff and a list of
@@ -267,7 +410,8 @@ func GetPullRequestWithDetails(
 //  2. Iterates over each file diff string. For each string, it:
 //     a. Attempts to parse the string into a GitDiff struct using the
 //     parseGitDiffFileString function. This function extracts the old and new
-//     file paths, index information, and the actual diff content.
+//     file paths, with their "a/" and "b/" prefixes stripped, index
+//     information, and the actual diff content.
 //     b. Checks for parsing errors. If an error occurs, it skips the current file
 //     diff and continues with the next one.
 //  3. Filters out file diffs based on the provided ignore list. The ignore list
@@ -285,18 +429,60 @@ func GetPullRequestWithDetails(
 // Returns:
 //   - A slice of GitDiff structs, each representing a parsed and non-ignored file diff.
 func ParseGitDiff(diff string, ignoreList []string) []*GitDiff {
-	files := splitDiffIntoFiles(diff)
+	return ParseGitDiffWithOptions(diff, ParseOptions{IgnoreList: ignoreList})
+}
+
+// ParseOptions configures how ParseGitDiffWithOptions parses and filters a
+// combined Git diff.
+type ParseOptions struct {
+	// IgnoreList contains regex patterns matched against the new path of
+	// each file diff. Matching file diffs are left out of the result.
+	IgnoreList []string
+
+	// KeepPathPrefixes keeps the "a/" and "b/" prefixes that git adds to
+	// FilePathOld and FilePathNew. By default they are stripped.
+	KeepPathPrefixes bool
+
+	// MaxFileBytes limits the size of a single file diff. Larger file
+	// diffs keep their header lines, so that their paths and status are
+	// still known, but their body is cut at the last line that fits and
+	// parsed as far as it goes, with GitDiff.Truncated set. A limit of 0
+	// or less means no limit.
+	MaxFileBytes int64
+}
+
+// ParseGitDiffWithOptions parses a combined Git diff in the same way as
+// ParseGitDiff, with additional control over the parsing provided by opts.
+//
+// Parameters:
+//   - diff: A string representing the combined Git diff.
+//   - opts: A ParseOptions struct containing the ignore list and other
+//     parsing settings.
+//
+// Returns:
+//   - A slice of GitDiff structs, each representing a parsed and non-ignored file diff.
+//
+// Example:
+//
+//	gitDiffs := ParseGitDiffWithOptions(diff, ParseOptions{
+//	  IgnoreList:       []string{`\.mod$`},
+//	  KeepPathPrefixes: true,
+//	})
+//	for _, gitDiff := range gitDiffs {
+//	  // gitDiff.FilePathNew starts with "b/"
+//	}
+func ParseGitDiffWithOptions(diff string, opts ParseOptions) []*GitDiff {
 	var filteredList []*GitDiff
 
-	for _, file := range files {
+	for _, chunk := range readDiffChunks(diff, opts.MaxFileBytes) {
 
-		gitDiff, err := parseGitDiffFileString(file)
+		gitDiff, err := parseGitDiffFileString(chunk.text, chunk.truncated, opts)
 
 		if err != nil {
 			continue
 		}
 
-		if matchIgnoreFilter(gitDiff, ignoreList) {
+		if matchIgnoreFilter(gitDiff, opts.IgnoreList) {
 			continue
 		}
 
@@ -306,58 +492,114 @@ func ParseGitDiff(diff string, ignoreList []string) []*GitDiff {
 	return filteredList
 }
 
-// getDiffContents retrieves the contents of a Git diff from a specified URL. The function
-// makes an HTTP GET request to the provided diffURL and returns the content as a string.
-// This function is designed to work with URLs pointing to raw diff data, typically used
-// in the context of GitHub or similar version control systems.
+// ParseGitDiffE parses a combined Git diff in the same way as
+// ParseGitDiffWithOptions, but reports problems to the caller instead of
+// silently discarding them.
+//
+// Every ignore pattern is compiled before parsing begins, and an invalid
+// pattern causes the function to fail immediately. File diffs that cannot
+// be parsed are skipped, and each one is recorded in a *ParseError along
+// with its byte offset and the reason it was skipped.
 //
 // Parameters:
-//   - diffURL: A string representing the URL from which the Git diff contents are to be retrieved.
+//   - diff: A string representing the combined Git diff.
+//   - opts: A ParseOptions struct containing the ignore list and other
+//     parsing settings.
 //
 // Returns:
-//   - A string containing the contents of the Git diff.
-//   - An error if the HTTP request fails, or if reading the response body fails.
-//
-// The function handles HTTP errors and read errors by returning an empty string and the
-// respective error. It ensures that the body of the HTTP response is read completely into
-// a byte slice, which is then converted into a string.
+//   - A slice of GitDiff structs, each representing a parsed and non-ignored
+//     file diff. It is returned even when some file diffs were skipped.
+//   - A *ParseError if one or more file diffs could not be parsed, or an
+//     error if an ignore pattern is not a valid regular expression.
 //
 // Example:
 //
-//	diff, err := getDiffContents("https://github.com/user/repo/pull/123.diff")
-//	if err != nil {
-//	  // Handle error
+//	gitDiffs, err := ParseGitDiffE(diff, ParseOptions{IgnoreList: ignoreList})
+//	var parseErr *ParseError
+//	if errors.As(err, &parseErr) {
+//	  for _, chunk := range parseErr.Chunks {
+//	    // Report chunk.Offset and chunk.Err
+//	  }
+//	} else if err != nil {
+//	  // Handle invalid ignore pattern
 //	}
-//	// Use diff as a string containing the Git diff
-//
-// This function is useful in scenarios where an application needs to process or analyze
-// the contents of a Git diff, such as in automated code review tools, continuous integration
-// systems, or other applications that interact with version control systems.
-func getDiffContents(diffURL string) (string, error) {
-	diffContents, err := http.Get(diffURL)
-	if err != nil {
-		return "", err
+func ParseGitDiffE(diff string, opts ParseOptions) ([]*GitDiff, error) {
+	reader := NewDiffReader(strings.NewReader(diff), opts)
+
+	var (
+		filteredList []*GitDiff
+		skipped      []*ChunkError
+	)
+
+	for {
+		gitDiff, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var chunkErr *ChunkError
+		if errors.As(err, &chunkErr) {
+			skipped = append(skipped, chunkErr)
+
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		filteredList = append(filteredList, gitDiff)
 	}
 
-	bodyBytes, err := io.ReadAll(diffContents.Body)
+	if len(skipped) > 0 {
+		return filteredList, &ParseError{Chunks: skipped}
+	}
+
+	return filteredList, nil
+}
+
+// readDiffBody reads a diff response body into a string and closes it.
+func readDiffBody(body io.ReadCloser) (string, error) {
+	defer closeBody(body)
+
+	bodyBytes, err := io.ReadAll(body)
 	if err != nil {
 		return "", err
 	}
 
-	// Close the body
-	defer func(Body io.ReadCloser) {
+	return string(bodyBytes), nil
+}
 
-		if err := Body.Close(); err != nil {
-			log.Printf("Error closing response body: %v", err)
-		}
+// openDiffURLWithClient makes an HTTP GET request to diffURL with the given
+// HTTP client and returns the response without reading its body. The body
+// is closed if the request does not succeed, and must be closed by the
+// caller otherwise.
+func openDiffURLWithClient(ctx context.Context, httpClient *http.Client, diffURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, diffURL, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	}(diffContents.Body)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
 
-	if diffContents.StatusCode != http.StatusOK {
-		return "", errors.New("failed to get diff contents")
+	if resp.StatusCode != http.StatusOK {
+		defer closeBody(resp.Body)
+
+		return nil, newHTTPStatusError(resp)
 	}
 
-	return string(bodyBytes), nil
+	return resp, nil
+}
+
+// closeBody closes an HTTP response body, logging any error since there is
+// nothing else the caller can do about it.
+func closeBody(body io.Closer) {
+	if err := body.Close(); err != nil {
+		log.Printf("Error closing response body: %v", err)
+	}
 }
 
 func matchIgnoreFilter(file *GitDiff, ignoreList []string) bool {
@@ -366,8 +608,9 @@ func matchIgnoreFilter(file *GitDiff, ignoreList []string) bool {
 		match, err := matchFile(pattern, file.FilePathNew)
 
 		if err != nil {
-			// consider finding a way to notify the caller
-			// an error has occurred.
+			// Invalid patterns never match here. Callers that need
+			// to know about them should use ParseGitDiffE or
+			// NewDiffReader, which report them as errors.
 			return false
 		}
 
@@ -404,93 +647,295 @@ func matchFile(pattern, file string) (bool, error) {
 // It assumes that 'diff --git' is used as a delimiter between file diffs.
 func splitDiffIntoFiles(diff string) []string {
 	var files []string
-	var curFile strings.Builder
-
-	scanner := bufio.NewScanner(strings.NewReader(diff))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.HasPrefix(line, "diff --git") {
-			// Detected start of new file
-			if curFile.Len() > 0 {
-				files = append(files, strings.TrimSpace(curFile.String()))
-				curFile.Reset()
-			}
-			curFile.WriteString(line + "\n")
-		} else {
-			curFile.WriteString(line + "\n")
+
+	for _, chunk := range splitDiffIntoChunks(diff) {
+		files = append(files, chunk.text)
+	}
+
+	return files
+}
+
+// diffChunk is the text of a single file diff along with the byte offset
+// at which it starts in the combined diff.
+type diffChunk struct {
+	offset int64
+	text   string
+
+	// truncated is set when the file diff was cut short by
+	// ParseOptions.MaxFileBytes while it was read.
+	truncated bool
+}
+
+// splitDiffIntoChunks splits a combined diff on 'diff --git' lines in the
+// same way as splitDiffIntoFiles, recording the offset of each chunk.
+// Surrounding whitespace is trimmed from each chunk, and chunks that are
+// empty after trimming are dropped.
+func splitDiffIntoChunks(diff string) []diffChunk {
+	return readDiffChunks(diff, 0)
+}
+
+// readDiffChunks splits a combined diff into chunks with a DiffReader,
+// cutting each one at maxFileBytes in the same way, so that
+// ParseGitDiffWithOptions and ParseGitDiffE produce the same file diffs.
+func readDiffChunks(diff string, maxFileBytes int64) []diffChunk {
+	reader := NewDiffReader(strings.NewReader(diff), ParseOptions{MaxFileBytes: maxFileBytes})
+
+	var chunks []diffChunk
+
+	for {
+		// Reading from a strings.Reader only fails with io.EOF.
+		chunk, err := reader.readChunk()
+		if err != nil {
+			return chunks
 		}
+
+		chunks = append(chunks, chunk)
 	}
+}
 
-	// Add the last file diff to the list
-	if curFile.Len() > 0 {
-		files = append(files, strings.TrimSpace(curFile.String()))
+// newDiffChunk trims the blank lines surrounding the raw text of a file
+// diff that starts at offset in the combined diff. It returns false if
+// nothing but whitespace remains.
+func newDiffChunk(raw string, offset int64) (diffChunk, bool) {
+	text, skipped := trimBlankLines(raw)
+	if text == "" {
+		return diffChunk{}, false
 	}
 
-	return files
+	return diffChunk{offset: offset + int64(skipped), text: normalizeLineEndings(text)}, true
+}
+
+// trimBlankLines removes the blank lines before and after the text of a
+// file diff, along with the newline ending its last line, and returns the
+// number of bytes removed from the start. Whitespace within the first and
+// last lines is kept, since it is part of the diff contents. Lines that
+// start with a space are only removed when they are not followed by a
+// newline, as otherwise they may be empty context lines.
+func trimBlankLines(raw string) (string, int) {
+	start := 0
+	for start < len(raw) {
+		end := strings.IndexByte(raw[start:], '\n')
+		if end < 0 || strings.TrimSpace(raw[start:start+end]) != "" {
+			break
+		}
+
+		start += end + 1
+	}
+
+	text := raw[start:]
+
+	if i := strings.LastIndexByte(text, '\n'); strings.TrimSpace(text[i+1:]) == "" {
+		text = text[:i+1]
+	}
+
+	for {
+		text = strings.TrimSuffix(text, "\n")
+
+		i := strings.LastIndexByte(text, '\n')
+		line := text[i+1:]
+
+		if strings.TrimSpace(line) != "" || strings.HasPrefix(line, " ") {
+			break
+		}
+
+		text = text[:i+1]
+		if text == "" {
+			break
+		}
+	}
+
+	return strings.TrimSuffix(text, "\r"), start
+}
+
+// splitLines splits text into lines in the same way as bufio.ScanLines,
+// dropping the final empty line after a trailing newline and any trailing
+// carriage returns, but without its limit on line length.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+
+	return lines
+}
+
+// normalizeLineEndings converts CRLF line endings to LF, matching the
+// line splitting of bufio.ScanLines.
+func normalizeLineEndings(text string) string {
+	if !strings.Contains(text, "\r\n") {
+		return text
+	}
+
+	return strings.ReplaceAll(text, "\r\n", "\n")
 }
 
 // ParseGitDiffFileString takes a string input representing a Git diff of a single file
 // and returns a GitDiff struct containing the parsed information. The input
-// string is expected to contain at least four lines, including the file paths
-// line, the index line, and the diff content. The function performs the following
-// steps to parse the diff:
+// string is expected to contain the file paths line followed by at least one
+// extended header line or hunk. Pure renames and mode-only changes carry no index line
+// or content and are returned with an empty Index and DiffContents. The function
+// performs the following steps to parse the diff:
 //  1. Split the input string into lines.
 //  2. Validate that there are enough lines to form a valid Git diff.
 //  3. Extract the old and new file paths from the first line. The line is
-//     expected to contain two file paths separated by a space.
-//  4. Extract the index information from the second line. The line should
-//     start with "index " followed by the index information.
-//  5. Join the remaining lines, starting from the third line, to form the
-//     diff content.
+//     expected to contain two file paths separated by a space. Quoted paths
+//     are unquoted, and paths containing spaces are disambiguated using the
+//     rename or copy headers and the "---" and "+++" lines. The "a/" and
+//     "b/" prefixes are stripped unless opts.KeepPathPrefixes is set.
+//  4. Parse the extended header lines that follow, such as "new file mode",
+//     "rename from" or "similarity index", into the Status, OldMode, NewMode
+//     and Similarity fields.
+//  5. Extract the index information from the line starting with "index ",
+//     if there is one.
+//  6. Join the remaining lines to form the diff content.
+//  7. Parse the diff content into structured hunks using ParseHunks.
+//  8. Detect binary files and decode any "GIT binary patch" section.
 //
 // The function returns an error if the input is not in the expected format,
 // such as if there are not enough lines, if the file paths line is invalid,
-// or if the index line is incorrectly formatted.
+// or if a hunk header or binary patch is incorrectly formatted.
 //
 // Parameters:
 //   - input: A string representing the Git diff of a single file.
+//   - truncated: Whether input was cut short by ParseOptions.MaxFileBytes, as
+//     reported by truncateFileDiff or DiffReader.
+//   - opts: A ParseOptions struct controlling how file paths are reported.
 //
 // Returns:
-//   - A pointer to a GitDiff struct containing the parsed file paths, index,
-//     and diff content.
+//   - A pointer to a GitDiff struct containing the parsed file paths, status,
+//     index, and diff content.
 //   - An error if the input string is not in the expected format or if any
 //     parsing step fails.
-func parseGitDiffFileString(input string) (*GitDiff, error) {
-	scanner := bufio.NewScanner(strings.NewReader(input))
-	scanner.Split(bufio.ScanLines)
-
+func parseGitDiffFileString(input string, truncated bool, opts ParseOptions) (*GitDiff, error) {
 	var (
-		filePaths []string
-		index     string
-		diff      []string
+		diffGitLine    string
+		hasDiffGitLine bool
+		hints          pathHints
+		index          string
+		diff           []string
+		inHeader       bool
+		hasHeader      bool
+		inHunks        bool
 	)
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	gitDiff := &GitDiff{Truncated: truncated}
 
+	for _, line := range splitLines(input) {
 		switch {
-		case strings.HasPrefix(line, "diff --git"):
-			filePaths = strings.Fields(line)[2:]
-			if len(filePaths) != 2 {
-				return nil, errors.New("invalid file paths")
-			}
-		case strings.HasPrefix(line, "index "):
+		case strings.HasPrefix(line, "diff --git "):
+			diffGitLine = strings.TrimPrefix(line, "diff --git ")
+			hasDiffGitLine = true
+			inHeader = true
+		case inHeader && strings.HasPrefix(line, "index "):
 			index = strings.TrimSpace(line[6:])
+			hasHeader = true
+		case inHeader && parseExtendedHeader(line, gitDiff):
+			parseHeaderPathHints(line, &hints)
+			hasHeader = true
 		default:
+			inHeader = false
+
+			switch {
+			case strings.HasPrefix(line, "@@ "):
+				inHunks = true
+			case !inHunks && strings.HasPrefix(line, "--- "):
+				hints.oldFile = parseFileLinePath(strings.TrimPrefix(line, "--- "))
+			case !inHunks && strings.HasPrefix(line, "+++ "):
+				hints.newFile = parseFileLinePath(strings.TrimPrefix(line, "+++ "))
+			}
+
 			diff = append(diff, line)
 		}
 	}
 
-	if len(filePaths) == 0 || len(index) == 0 || len(diff) == 0 {
+	if !hasDiffGitLine {
 		return nil, errors.New("invalid git diff format")
 	}
 
-	return &GitDiff{
-		FilePathOld:  filePaths[0],
-		FilePathNew:  filePaths[1],
-		Index:        index,
-		DiffContents: strings.Join(diff, "\n"),
-	}, nil
+	oldPath, newPath, err := parseDiffGitPaths(diffGitLine, hints)
+	if err != nil {
+		return nil, err
+	}
+
+	diffContents := strings.Join(diff, "\n")
+
+	hunks, err := ParseHunks(diffContents)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pure renames and mode changes have neither an index line nor any
+	// hunks, but are still valid as long as some header describes them.
+	// A truncated diff may have lost its hunks to the size limit.
+	if !hasHeader && len(hunks) == 0 && !truncated {
+		return nil, errors.New("invalid git diff format")
+	}
+
+	// A binary patch cut short by MaxFileBytes cannot be decoded, but the
+	// file is still known to be binary.
+	if err := parseBinaryContents(diff, gitDiff); err != nil && !gitDiff.Truncated {
+		return nil, err
+	} else if err != nil {
+		gitDiff.IsBinary = true
+	}
+
+	parseIndexModes(index, gitDiff)
+
+	if !opts.KeepPathPrefixes {
+		oldPath, newPath = stripDiffPrefixes(oldPath, newPath, hints)
+	}
+
+	gitDiff.FilePathOld = oldPath
+	gitDiff.FilePathNew = newPath
+	gitDiff.Index = index
+	gitDiff.DiffContents = diffContents
+	gitDiff.Hunks = hunks
+
+	return gitDiff, nil
+}
+
+// truncateFileDiff cuts the body of a file diff longer than maxBytes at the
+// end of the last complete line that fits, with the chunkBuilder used by
+// DiffReader, and reports whether lines that are not blank were cut. The
+// header lines that precede the body, as reported by isFileHeaderLine, are
+// always kept, even if they alone exceed maxBytes.
+func truncateFileDiff(input string, maxBytes int64) (string, bool) {
+	if maxBytes <= 0 || int64(len(input)) <= maxBytes {
+		return input, false
+	}
+
+	builder := chunkBuilder{maxBytes: maxBytes}
+
+	for _, line := range strings.SplitAfter(input, "\n") {
+		if line != "" {
+			builder.writeLine([]byte(line))
+		}
+	}
+
+	if !builder.cut {
+		return input, false
+	}
+
+	return strings.TrimSuffix(builder.buf.String(), "\n"), builder.truncated
+}
+
+// isFileHeaderLine reports whether line is one of the header lines that
+// can precede the hunks of a file diff, such as its "diff --git", "index"
+// or "---" line.
+func isFileHeaderLine(line string) bool {
+	switch {
+	case strings.HasPrefix(line, "diff --git "),
+		strings.HasPrefix(line, "index "),
+		strings.HasPrefix(line, "--- "),
+		strings.HasPrefix(line, "+++ "):
+		return true
+	default:
+		return parseExtendedHeader(line, &GitDiff{})
+	}
 }
 
 func getFileExtension(path string) string {
@@ -25,6 +25,15 @@ func TestGithub_ParseGithubPullRequestURL(t *testing.T) {
 	}
 }
 
+func TestGithub_ParseEnterprisePullRequestURL(t *testing.T) {
+	pullRequestURL, err := ParsePullRequestURL(
+		"https://ghe.example.com/org/project/pull/42",
+	)
+
+	require.NoError(t, err)
+	require.Equal(t, &PullRequestURL{Host: "ghe.example.com", Owner: "org", Repo: "project", PRNumber: 42}, pullRequestURL)
+}
+
 func TestGithub_ParseGithubPullRequestInvalidURL(t *testing.T) {
 	pullRequestURL, err := ParsePullRequestURL("foo")
 
@@ -168,6 +177,42 @@ diff --git c/f2 d/f2
 	}
 }
 
+func TestParseGitDiff_KeepsTrailingWhitespaceOfLastLine(t *testing.T) {
+	diff := "diff --git a/a.txt b/a.txt\n" +
+		"index 1111111..2222222 100644\n" +
+		"--- a/a.txt\n" +
+		"+++ b/a.txt\n" +
+		"@@ -1 +1,2 @@\n" +
+		" keep\n" +
+		"+trailing ws  \t\n" +
+		"\n" +
+		"diff --git a/b.txt b/b.txt\n" +
+		"index 3333333..4444444 100644\n" +
+		"--- a/b.txt\n" +
+		"+++ b/b.txt\n" +
+		"@@ -1 +1 @@\n" +
+		"-x\n" +
+		"+-- \n"
+
+	for _, gitDiffs := range [][]*GitDiff{
+		ParseGitDiff(diff, nil),
+		mustParseGitDiffE(t, diff),
+	} {
+		require.Len(t, gitDiffs, 2)
+		require.Equal(t, "trailing ws  \t", gitDiffs[0].Hunks[0].Lines[1].Content)
+		require.Equal(t, "-- ", gitDiffs[1].Hunks[0].Lines[1].Content)
+	}
+}
+
+func mustParseGitDiffE(t *testing.T, diff string) []*GitDiff {
+	t.Helper()
+
+	gitDiffs, err := ParseGitDiffE(diff, ParseOptions{})
+	require.NoError(t, err)
+
+	return gitDiffs
+}
+
 func TestParseGitDiffFileString(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -184,10 +229,20 @@ index 123abc..456def 100644
 @@ -1,3 +1,4 @@
 +import "fmt"`,
 			want: &GitDiff{
-				FilePathOld:  "a/file1.go",
-				FilePathNew:  "b/file1.go",
+				FilePathOld:  "file1.go",
+				FilePathNew:  "file1.go",
+				OldMode:      "100644",
+				NewMode:      "100644",
 				Index:        "123abc..456def 100644",
 				DiffContents: "--- a/file1.go\n+++ b/file1.go\n@@ -1,3 +1,4 @@\n+import \"fmt\"",
+				Hunks: []*Hunk{
+					{
+						OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 4,
+						Lines: []*HunkLine{
+							{Type: LineAdded, Content: `import "fmt"`, NewLineNo: 1},
+						},
+					},
+				},
 			},
 			wantErr: nil,
 		},
@@ -208,6 +263,12 @@ index 123abc..456def 100644
 			want:    nil,
 			wantErr: errors.New("invalid git diff format"),
 		},
+		{
+			name:    "Missing Header And Contents",
+			input:   `diff --git a/file1.go b/file1.go`,
+			want:    nil,
+			wantErr: errors.New("invalid git diff format"),
+		},
 		{
 			name:    "Empty Input",
 			input:   "",
@@ -218,7 +279,7 @@ index 123abc..456def 100644
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseGitDiffFileString(tt.input)
+			got, err := parseGitDiffFileString(tt.input, false, ParseOptions{})
 			if (err != nil) != (tt.wantErr != nil) || (err != nil && err.Error() != tt.wantErr.Error()) {
 				t.Errorf("parseGitDiffFileString() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -248,10 +309,20 @@ index 234bcd..567efg 100644
 
 	expected := []*GitDiff{
 		{
-			FilePathOld:  "a/file1.go",
-			FilePathNew:  "b/file1.go",
+			FilePathOld:  "file1.go",
+			FilePathNew:  "file1.go",
+			OldMode:      "100644",
+			NewMode:      "100644",
 			Index:        "123abc..456def 100644",
 			DiffContents: "--- a/file1.go\n+++ b/file1.go\n@@ -1,3 +1,4 @@\n+import \"fmt\"",
+			Hunks: []*Hunk{
+				{
+					OldStart: 1, OldLines: 3, NewStart: 1, NewLines: 4,
+					Lines: []*HunkLine{
+						{Type: LineAdded, Content: `import "fmt"`, NewLineNo: 1},
+					},
+				},
+			},
 		},
 		// go.mod is ignored based on the ignoreList
 	}
@@ -263,7 +334,7 @@ index 234bcd..567efg 100644
 	}
 }
 
-func TestGetDiffContents(t *testing.T) {
+func TestFetcher_DiffFromDiffURL(t *testing.T) {
 	// Mock HTTP server
 	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/valid-diff" {
@@ -297,13 +368,22 @@ func TestGetDiffContents(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getDiffContents(tt.diffURL)
+			fetcher, err := NewFetcher(WithGitHubClient(&clientWithoutRaw{mock: &MockGitClient{
+				MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+					return &github.PullRequest{DiffURL: github.String(tt.diffURL)}, nil, nil
+				},
+			}}))
+			if err != nil {
+				t.Fatalf("NewFetcher() error = %v", err)
+			}
+
+			got, err := fetcher.Diff(context.Background(), &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123})
 			if (err != nil) != tt.wantErr {
-				t.Errorf("getDiffContents() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("Fetcher.Diff() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("getDiffContents() = %v, want %v", got, tt.want)
+				t.Errorf("Fetcher.Diff() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -658,3 +738,61 @@ func TestGetPullRequestWithClient_InvalidURL(t *testing.T) {
 	require.Error(t, err)
 	require.Empty(t, diff)
 }
+
+func TestGetPullRequestDiffReader(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/valid-diff" {
+			_, _ = w.Write([]byte("diff --git a/file1.go b/file1.go\nindex 1..2 100644\n--- a/file1.go\n+++ b/file1.go\n@@ -1 +1 @@\n-a\n+b\n"))
+		} else {
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	clientFor := func(diffURL string) *MockGitClient {
+		return &MockGitClient{
+			MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+				return &github.PullRequest{DiffURL: github.String(diffURL)}, nil, nil
+			},
+		}
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 123}
+
+	t.Run("Streams the diff", func(t *testing.T) {
+		body, err := GetPullRequestDiffReader(context.Background(), prURL, clientFor(testServer.URL+"/valid-diff"))
+		require.NoError(t, err)
+		defer body.Close()
+
+		gitDiff, err := NewDiffReader(body, ParseOptions{}).Next()
+		require.NoError(t, err)
+		require.Equal(t, "file1.go", gitDiff.FilePathNew)
+	})
+
+	t.Run("Fails on non-200 status", func(t *testing.T) {
+		body, err := GetPullRequestDiffReader(context.Background(), prURL, clientFor(testServer.URL+"/invalid-diff"))
+		require.Error(t, err)
+		require.Nil(t, body)
+	})
+
+	t.Run("Respects context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		body, err := GetPullRequestDiffReader(ctx, prURL, clientFor(testServer.URL+"/valid-diff"))
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, body)
+	})
+
+	t.Run("Fails when the pull request lookup fails", func(t *testing.T) {
+		mockClient := &MockGitClient{
+			MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+				return nil, nil, errors.New("API error")
+			},
+		}
+
+		body, err := GetPullRequestDiffReader(context.Background(), prURL, mockClient)
+		require.ErrorContains(t, err, "API error")
+		require.Nil(t, body)
+	})
+}
@@ -0,0 +1,309 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// listFilesPerPage is the page size used when listing the files of a pull
+// request, which is the maximum allowed by the GitHub API.
+const listFilesPerPage = 100
+
+// GitHubFilesClientInterface extends GitHubClientInterface with the ability to list the
+// files changed by a pull request, together with the patch of each file. It is used by
+// GetPullRequestGitDiffs when GitHub refuses to render the diff of a pull request as a
+// whole because it is too large.
+type GitHubFilesClientInterface interface {
+	GitHubClientInterface
+
+	// ListFiles lists one page of the files changed by a specific pull request, based on
+	// the provided owner, repository name, and pull request number.
+	ListFiles(
+		ctx context.Context,
+		owner string,
+		repo string,
+		number int,
+		opts *github.ListOptions,
+	) ([]*github.CommitFile, *github.Response, error)
+}
+
+// ListFiles lists one page of the files changed by a pull request using the
+// PullRequests service of the wrapped client.
+func (c *GitHubClientWrapper) ListFiles(
+	ctx context.Context,
+	owner string,
+	repo string,
+	number int,
+	opts *github.ListOptions,
+) ([]*github.CommitFile, *github.Response, error) {
+	return c.PullRequests.ListFiles(ctx, owner, repo, number, opts)
+}
+
+// ListFiles calls the mock implementation of the ListFiles method. If MockListFiles is set
+// to a custom function, that function is executed and its result returned. If MockListFiles
+// is not set, the method returns an error.
+func (m *MockGitClient) ListFiles(
+	ctx context.Context,
+	owner string,
+	repo string,
+	number int,
+	opts *github.ListOptions,
+) ([]*github.CommitFile, *github.Response, error) {
+	if m.MockListFiles != nil {
+		return m.MockListFiles(ctx, owner, repo, number, opts)
+	}
+
+	return nil, nil, errMockNotSet("MockListFiles")
+}
+
+// GetPullRequestGitDiffs retrieves the Git diff of a pull request and parses it into one
+// GitDiff per file. It is equivalent to calling GetPullRequestWithClient followed by
+// ParseGitDiffE, except that it also works for pull requests whose diff GitHub refuses to
+// render because it exceeds GitHub's size limits.
+//
+// When GitHub rejects the diff download with an error matching ErrDiffTooLarge and the client
+// implements GitHubFilesClientInterface, as GitHubClientWrapper does, the function pages
+// through the files of the pull request instead and rebuilds a GitDiff from the patch
+// GitHub returns for each of them. GitHub shortens or omits the patch of very large files;
+// the GitDiff of such a file has its Truncated field set. GitHub omits the patch of binary
+// files too, whose GitDiff has IsBinary set instead. A *DiffSizeError caused by the
+// limit set with WithMaxBytes is returned as is, without falling back to the file list.
+//
+// Parameters:
+//   - ctx: A context.Context object, used for managing the lifecycle of the requests.
+//   - pr: A pointer to a PullRequestURL struct, containing the owner, repository, and pull request number.
+//   - client: An implementation of the GitHubClientInterface, used to download the diff.
+//   - opts: A ParseOptions struct containing the ignore list and other parsing settings,
+//     which are applied to the rebuilt file diffs as well.
+//
+// Returns:
+//   - A slice of GitDiff structs, each representing a parsed and non-ignored file diff.
+//   - An error if the diff or the file list cannot be retrieved, or a *ParseError if some
+//     file diffs could not be parsed, in which case the remaining file diffs are still returned.
+//
+// Example:
+//
+//	gitDiffs, err := GetPullRequestGitDiffs(ctx, prURL, ghClient, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+//	for _, gitDiff := range gitDiffs {
+//	  if gitDiff.Truncated {
+//	    // Only part of the changes to gitDiff.FilePathNew is available
+//	  }
+//	}
+func GetPullRequestGitDiffs(
+	ctx context.Context,
+	pr *PullRequestURL,
+	client GitHubClientInterface,
+	opts ParseOptions,
+) ([]*GitDiff, error) {
+	fetcher, err := NewFetcher(WithGitHubClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.PullRequestGitDiffs(ctx, pr, opts)
+}
+
+// PullRequestGitDiffs retrieves the Git diff of a pull request with Diff and parses it with
+// ParseGitDiffE, rebuilding the file diffs from the file list of the pull request if the
+// diff is too large, as described for GetPullRequestGitDiffs.
+func (f *Fetcher) PullRequestGitDiffs(ctx context.Context, pr *PullRequestURL, opts ParseOptions) ([]*GitDiff, error) {
+	return f.pullRequestGitDiffs(ctx, pr, opts, nil)
+}
+
+// pullRequestGitDiffs implements PullRequestGitDiffs, using files as the
+// file list of the pull request if it is not nil.
+func (f *Fetcher) pullRequestGitDiffs(
+	ctx context.Context,
+	pr *PullRequestURL,
+	opts ParseOptions,
+	files []*github.CommitFile,
+) ([]*GitDiff, error) {
+	diff, err := f.Diff(ctx, pr)
+	if err == nil {
+		return ParseGitDiffE(diff, opts)
+	}
+
+	// A *DiffSizeError means the diff exceeded the Fetcher's own size limit
+	// rather than GitHub's, and the file list would not respect that limit.
+	var sizeErr *DiffSizeError
+	if !errors.Is(err, ErrDiffTooLarge) || errors.As(err, &sizeErr) {
+		return nil, err
+	}
+
+	client, clientErr := f.gitHubClient(pr.Host)
+	if clientErr != nil {
+		return nil, clientErr
+	}
+
+	filesClient, ok := client.(GitHubFilesClientInterface)
+	if !ok {
+		return nil, err
+	}
+
+	if files == nil {
+		files, err = listPullRequestFiles(ctx, pr, filesClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return gitDiffsFromCommitFiles(files, opts)
+}
+
+// listPullRequestFiles retrieves every page of the files changed by a pull
+// request.
+func listPullRequestFiles(
+	ctx context.Context,
+	pr *PullRequestURL,
+	client GitHubFilesClientInterface,
+) ([]*github.CommitFile, error) {
+	var files []*github.CommitFile
+
+	listOptions := &github.ListOptions{PerPage: listFilesPerPage}
+
+	for {
+		page, resp, err := client.ListFiles(ctx, pr.Owner, pr.Repo, pr.PRNumber, listOptions)
+		if err != nil {
+			return nil, wrapGitHubError(resp, err)
+		}
+
+		files = append(files, page...)
+
+		if resp == nil || resp.NextPage == 0 {
+			return files, nil
+		}
+
+		listOptions.Page = resp.NextPage
+	}
+}
+
+// gitDiffsFromCommitFiles converts the files of a pull request into file
+// diffs, dropping the ones matched by the ignore list.
+func gitDiffsFromCommitFiles(files []*github.CommitFile, opts ParseOptions) ([]*GitDiff, error) {
+	ignoreList, err := compileIgnoreList(opts.IgnoreList)
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredList []*GitDiff
+
+	for _, file := range files {
+		gitDiff := gitDiffFromCommitFile(file, opts)
+
+		if matchCompiledIgnoreList(gitDiff, ignoreList) {
+			continue
+		}
+
+		filteredList = append(filteredList, gitDiff)
+	}
+
+	return filteredList, nil
+}
+
+// gitDiffFromCommitFile rebuilds the diff of a single file from the file
+// entry returned by the GitHub API with gitDiffFromPatch. A patch that is
+// missing or has fewer changed lines than the counts reported by GitHub
+// also marks the diff as truncated. GitHub omits the patch of binary files
+// as well, but reports no changed lines for them, so a file without a patch
+// or changed lines is marked as binary unless it was only renamed or copied.
+func gitDiffFromCommitFile(file *github.CommitFile, opts ParseOptions) *GitDiff {
+	oldPath := file.GetPreviousFilename()
+	if oldPath == "" {
+		oldPath = file.GetFilename()
+	}
+
+	gitDiff := gitDiffFromPatch(oldPath, file.GetFilename(), commitFileStatus(file.GetStatus()), file.GetPatch(), opts)
+	gitDiff.CommitFile = file
+
+	if gitDiff.DiffContents == "" {
+		gitDiff.Truncated = file.GetChanges() > 0
+		gitDiff.IsBinary = !gitDiff.Truncated && gitDiff.Status != StatusRenamed && gitDiff.Status != StatusCopied
+
+		return gitDiff
+	}
+
+	var additions, deletions int
+	for _, hunk := range gitDiff.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case LineAdded:
+				additions++
+			case LineRemoved:
+				deletions++
+			}
+		}
+	}
+
+	gitDiff.Truncated = gitDiff.Truncated || additions < file.GetAdditions() || deletions < file.GetDeletions()
+
+	return gitDiff
+}
+
+// gitDiffFromPatch builds the diff of a single file from its paths, status
+// and patch, as returned by the APIs that list the files of a change along
+// with their hunks but without their headers. The "---" and "+++" lines are
+// added in front of the patch so that DiffContents has the same shape as
+// for a parsed diff. A patch cut short by ParseOptions.MaxFileBytes, or
+// whose hunks cannot be parsed, marks the diff as truncated. DiffContents
+// is left empty when the patch is.
+func gitDiffFromPatch(oldPath, newPath string, status FileStatus, patch string, opts ParseOptions) *GitDiff {
+	gitDiff := &GitDiff{
+		FilePathOld: oldPath,
+		FilePathNew: newPath,
+		Status:      status,
+	}
+
+	oldHeader, newHeader := "a/"+oldPath, "b/"+newPath
+	switch status {
+	case StatusAdded:
+		oldHeader = "/dev/null"
+	case StatusDeleted:
+		newHeader = "/dev/null"
+	}
+
+	if opts.KeepPathPrefixes {
+		gitDiff.FilePathOld, gitDiff.FilePathNew = "a/"+oldPath, "b/"+newPath
+	}
+
+	patch = strings.TrimRight(patch, "\n")
+	if patch == "" {
+		return gitDiff
+	}
+
+	patch, gitDiff.Truncated = truncateFileDiff(patch, opts.MaxFileBytes)
+	gitDiff.DiffContents = "--- " + oldHeader + "\n+++ " + newHeader + "\n" + patch
+
+	hunks, err := ParseHunks(patch)
+	if err != nil {
+		gitDiff.Truncated = true
+
+		return gitDiff
+	}
+
+	gitDiff.Hunks = hunks
+
+	return gitDiff
+}
+
+// commitFileStatus converts the status of a file returned by the GitHub API
+// into a FileStatus.
+func commitFileStatus(status string) FileStatus {
+	switch status {
+	case "added":
+		return StatusAdded
+	case "removed":
+		return StatusDeleted
+	case "renamed":
+		return StatusRenamed
+	case "copied":
+		return StatusCopied
+	default:
+		return StatusModified
+	}
+}
@@ -0,0 +1,248 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPullRequestGitDiffs_DiffTooLargeFallback(t *testing.T) {
+	files := []*github.CommitFile{
+		{
+			Filename:  github.String("go.mod"),
+			Status:    github.String("modified"),
+			Additions: github.Int(1),
+			Deletions: github.Int(1),
+			Changes:   github.Int(2),
+			Patch:     github.String("@@ -3 +3 @@\n-require a v1.0.0\n+require a v1.1.0"),
+		},
+		{
+			Filename:  github.String("main.go"),
+			Status:    github.String("added"),
+			Additions: github.Int(1),
+			Changes:   github.Int(1),
+			Patch:     github.String("@@ -0,0 +1 @@\n+package main"),
+		},
+		{
+			Filename:         github.String("new.go"),
+			PreviousFilename: github.String("old.go"),
+			Status:           github.String("renamed"),
+		},
+		{
+			Filename:  github.String("vendor/huge.go"),
+			Status:    github.String("modified"),
+			Additions: github.Int(50000),
+			Changes:   github.Int(50000),
+		},
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/user/repo/pulls/1":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotAcceptable)
+			_, _ = w.Write([]byte(`{"message": "Sorry, the diff exceeded the maximum number of lines (20000)",` +
+				`"errors": [{"resource": "PullRequest", "field": "diff", "code": "too_large"}]}`))
+		case "/repos/user/repo/pulls/1/files":
+			page := files[:2]
+			if r.URL.Query().Get("page") == "2" {
+				page = files[2:]
+			} else {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=2&per_page=100>; rel="next"`, "http://"+r.Host, r.URL.Path))
+			}
+
+			_ = json.NewEncoder(w).Encode(page)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer testServer.Close()
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 1}
+
+	gitDiffs, err := GetPullRequestGitDiffs(
+		context.Background(),
+		prURL,
+		newTestGitHubClient(t, testServer, ""),
+		ParseOptions{IgnoreList: []string{`\.mod$`}},
+	)
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 3)
+
+	require.Equal(t, "main.go", gitDiffs[0].FilePathNew)
+	require.Equal(t, StatusAdded, gitDiffs[0].Status)
+	require.Equal(t, "--- /dev/null\n+++ b/main.go\n@@ -0,0 +1 @@\n+package main", gitDiffs[0].DiffContents)
+	require.Len(t, gitDiffs[0].Hunks, 1)
+	require.Equal(t, 1, gitDiffs[0].Hunks[0].Lines[0].NewLineNo)
+	require.False(t, gitDiffs[0].Truncated)
+
+	require.Equal(t, "old.go", gitDiffs[1].FilePathOld)
+	require.Equal(t, "new.go", gitDiffs[1].FilePathNew)
+	require.Equal(t, StatusRenamed, gitDiffs[1].Status)
+	require.False(t, gitDiffs[1].Truncated)
+
+	require.Equal(t, "vendor/huge.go", gitDiffs[2].FilePathNew)
+	require.Empty(t, gitDiffs[2].DiffContents)
+	require.True(t, gitDiffs[2].Truncated)
+}
+
+func TestGetPullRequestGitDiffs_ParsesDiff(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockGetRaw: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			return io.NopCloser(strings.NewReader("diff --git a/file1.go b/file1.go\nindex 1..2 100644\n")), nil, nil
+		},
+		MockListFiles: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts *github.ListOptions,
+		) ([]*github.CommitFile, *github.Response, error) {
+			t.Error("ListFiles must not be called when the diff can be downloaded")
+
+			return nil, nil, nil
+		},
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 1}
+	gitDiffs, err := GetPullRequestGitDiffs(context.Background(), prURL, mockClient, ParseOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "file1.go", gitDiffs[0].FilePathNew)
+}
+
+func TestGetPullRequestGitDiffs_OtherErrorsAreReturned(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockGetRaw: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			return nil, nil, &HTTPStatusError{StatusCode: http.StatusNotFound}
+		},
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 1}
+	_, err := GetPullRequestGitDiffs(context.Background(), prURL, mockClient, ParseOptions{})
+
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFetcherPullRequestGitDiffs_MaxBytesDoesNotFallBack(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockGetRaw: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			return io.NopCloser(strings.NewReader(strings.Repeat("+", 5000))), nil, nil
+		},
+		MockListFiles: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts *github.ListOptions,
+		) ([]*github.CommitFile, *github.Response, error) {
+			t.Error("ListFiles must not be called when the local size limit is exceeded")
+
+			return nil, nil, nil
+		},
+	}
+
+	fetcher, err := NewFetcher(WithGitHubClient(mockClient), WithMaxBytes(100))
+	require.NoError(t, err)
+
+	gitDiffs, err := fetcher.PullRequestGitDiffs(context.Background(), &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 1}, ParseOptions{})
+
+	var sizeErr *DiffSizeError
+	require.ErrorAs(t, err, &sizeErr)
+	require.Equal(t, int64(100), sizeErr.Limit)
+	require.Nil(t, gitDiffs)
+}
+
+func TestGitDiffFromCommitFile_TruncatedPatch(t *testing.T) {
+	file := &github.CommitFile{
+		Filename:  github.String("removed.go"),
+		Status:    github.String("removed"),
+		Deletions: github.Int(3),
+		Changes:   github.Int(3),
+		Patch:     github.String("@@ -1,3 +0,0 @@\n-package a\n-\n"),
+	}
+
+	gitDiff := gitDiffFromCommitFile(file, ParseOptions{KeepPathPrefixes: true})
+
+	require.Equal(t, StatusDeleted, gitDiff.Status)
+	require.Equal(t, "a/removed.go", gitDiff.FilePathOld)
+	require.Equal(t, "b/removed.go", gitDiff.FilePathNew)
+	require.True(t, strings.HasPrefix(gitDiff.DiffContents, "--- a/removed.go\n+++ /dev/null\n"))
+	require.True(t, gitDiff.Truncated)
+}
+
+func TestGitDiffFromCommitFile_MaxFileBytes(t *testing.T) {
+	file := &github.CommitFile{
+		Filename: github.String("big.go"),
+		Status:   github.String("added"),
+		Patch:    github.String("@@ -0,0 +1,2 @@\n+" + strings.Repeat("a", 100) + "\n+" + strings.Repeat("b", 100)),
+	}
+
+	gitDiff := gitDiffFromCommitFile(file, ParseOptions{MaxFileBytes: 150})
+
+	require.True(t, gitDiff.Truncated)
+	require.Equal(t, "--- /dev/null\n+++ b/big.go\n@@ -0,0 +1,2 @@\n+"+strings.Repeat("a", 100), gitDiff.DiffContents)
+	require.Len(t, gitDiff.Hunks[0].Lines, 1)
+
+	gitDiff = gitDiffFromCommitFile(file, ParseOptions{})
+
+	require.False(t, gitDiff.Truncated)
+	require.Len(t, gitDiff.Hunks[0].Lines, 2)
+}
+
+func TestGitDiffFromCommitFile_WithoutPatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      *github.CommitFile
+		binary    bool
+		truncated bool
+	}{
+		{
+			name:   "Binary",
+			file:   &github.CommitFile{Filename: github.String("logo.png"), Status: github.String("modified")},
+			binary: true,
+		},
+		{
+			name:   "Renamed without changes",
+			file:   &github.CommitFile{Filename: github.String("new.png"), PreviousFilename: github.String("old.png"), Status: github.String("renamed")},
+			binary: false,
+		},
+		{
+			name:      "Too large",
+			file:      &github.CommitFile{Filename: github.String("big.go"), Status: github.String("modified"), Additions: github.Int(5000), Changes: github.Int(5000)},
+			truncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitDiff := gitDiffFromCommitFile(tt.file, ParseOptions{})
+
+			require.Equal(t, tt.binary, gitDiff.IsBinary)
+			require.Equal(t, tt.truncated, gitDiff.Truncated)
+			require.Empty(t, gitDiff.DiffContents)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ownerRegex and repoRegex match the characters GitHub allows in
+	// account and repository names.
+	ownerRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	repoRegex  = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+	// pullRequestShorthandRegex matches "owner/repo#123" references, with an
+	// optional host prefix such as "ghe.example.com/owner/repo#123".
+	pullRequestShorthandRegex = regexp.MustCompile(`^(?:([^/\s]+)/)?([A-Za-z0-9_.-]+)/([A-Za-z0-9_.-]+)#(\d+)$`)
+)
+
+// splitGitHubURL parses a GitHub web or API URL into its host and path
+// segments. URLs without a scheme are accepted: if the first segment looks
+// like a host name it is used as the host, otherwise the host defaults to
+// github.com. Query strings, fragments and empty segments are dropped, and
+// the "repos" prefix of API URLs ("/repos/..." on api.github.com and
+// "/api/v3/repos/..." on GitHub Enterprise Server) is removed so that web
+// and API URLs produce the same segments.
+func splitGitHubURL(rawURL string) (string, []string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+
+	if !strings.Contains(rawURL, "://") {
+		firstSegment, _, _ := strings.Cut(rawURL, "/")
+		if !strings.ContainsAny(firstSegment, ".:") {
+			rawURL = "github.com/" + rawURL
+		}
+
+		rawURL = "https://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return "", nil, fmt.Errorf("%w: unsupported scheme %q", ErrInvalidURL, parsed.Scheme)
+	}
+
+	host := strings.ToLower(parsed.Host)
+	if parsed.Hostname() == "" {
+		return "", nil, fmt.Errorf("%w: missing host", ErrInvalidURL)
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(parsed.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	switch {
+	case host == "api.github.com" && len(segments) > 0 && segments[0] == "repos":
+		host = "github.com"
+		segments = segments[1:]
+	case len(segments) > 2 && segments[0] == "api" && segments[1] == "v3" && segments[2] == "repos":
+		segments = segments[3:]
+	}
+
+	if host == "www.github.com" {
+		host = "github.com"
+	}
+
+	return host, segments, nil
+}
+
+// parseRepoSegments validates the owner and repository name at the start
+// of a path.
+func parseRepoSegments(segments []string) (string, string, bool) {
+	if len(segments) < 2 || !ownerRegex.MatchString(segments[0]) || !repoRegex.MatchString(segments[1]) {
+		return "", "", false
+	}
+
+	return segments[0], strings.TrimSuffix(segments[1], ".git"), true
+}
+
+// parsePositiveInt parses a pull request number, rejecting zero, negative
+// numbers and anything that is not entirely digits.
+func parsePositiveInt(s string) (int, bool) {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// otherProviderHosts contains the hosts of the providers other than GitHub
+// that have their own DiffSource, whose URLs are never GitHub pull requests.
+var otherProviderHosts = map[string]bool{
+	"gitlab.com":        true,
+	"www.gitlab.com":    true,
+	"bitbucket.org":     true,
+	"www.bitbucket.org": true,
+	"api.bitbucket.org": true,
+}
+
+// parsePullRequestReference parses the forms accepted by
+// ParsePullRequestURL.
+func parsePullRequestReference(reference string) (*PullRequestURL, bool) {
+	return parsePullRequestReferenceInRepo(reference, "")
+}
+
+// parsePullRequestReferenceInRepo parses the forms accepted by
+// ParsePullRequestReference. An empty defaultRepo only allows references
+// that name their repository.
+func parsePullRequestReferenceInRepo(reference string, defaultRepo string) (*PullRequestURL, bool) {
+	fields := strings.Fields(reference)
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	if len(fields) > 1 {
+		return parseGHReference(fields, defaultRepo)
+	}
+
+	reference = fields[0]
+
+	if number, ok := strings.CutPrefix(reference, "#"); ok {
+		return parseNumberInRepo(number, defaultRepo)
+	}
+
+	if _, ok := parsePositiveInt(reference); ok {
+		return parseNumberInRepo(reference, defaultRepo)
+	}
+
+	if matches := pullRequestShorthandRegex.FindStringSubmatch(reference); matches != nil {
+		prNumber, ok := parsePositiveInt(matches[4])
+		if !ok {
+			return nil, false
+		}
+
+		// As in splitGitHubURL, a host is told apart from an owner by the
+		// dot or port separator it contains.
+		host := strings.ToLower(matches[1])
+		switch {
+		case host == "" || host == "www.github.com":
+			host = "github.com"
+		case !strings.ContainsAny(host, ".:") || otherProviderHosts[host]:
+			return nil, false
+		}
+
+		return &PullRequestURL{
+			Host:     host,
+			Owner:    matches[2],
+			Repo:     matches[3],
+			PRNumber: prNumber,
+		}, true
+	}
+
+	host, segments, err := splitGitHubURL(reference)
+	if err != nil || otherProviderHosts[host] {
+		return nil, false
+	}
+
+	owner, repo, ok := parseRepoSegments(segments)
+	if !ok || len(segments) < 4 || (segments[2] != "pull" && segments[2] != "pulls") {
+		return nil, false
+	}
+
+	number, rest := segments[3], segments[4:]
+
+	// The .diff and .patch forms of the pull request page take no
+	// sub-page.
+	if trimmed := strings.TrimSuffix(strings.TrimSuffix(number, ".diff"), ".patch"); trimmed != number {
+		if len(rest) > 0 {
+			return nil, false
+		}
+
+		number = trimmed
+	}
+
+	if !isPullRequestSubPage(rest) {
+		return nil, false
+	}
+
+	prNumber, ok := parsePositiveInt(number)
+	if !ok {
+		return nil, false
+	}
+
+	return &PullRequestURL{
+		Host:     host,
+		Owner:    owner,
+		Repo:     repo,
+		PRNumber: prNumber,
+	}, true
+}
+
+// isPullRequestSubPage reports whether the path segments that follow the
+// pull request number are a known sub-page: /files, /commits or
+// /commits/<sha>.
+func isPullRequestSubPage(rest []string) bool {
+	switch {
+	case len(rest) == 0:
+		return true
+	case len(rest) == 1:
+		return rest[0] == "files" || rest[0] == "commits"
+	case len(rest) == 2:
+		return rest[0] == "commits" && commitSHARegex.MatchString(rest[1])
+	default:
+		return false
+	}
+}
+
+// parseGHReference parses the arguments of a gh CLI command selecting a
+// pull request, such as "12 -R owner/repo" or "--repo=owner/repo #12".
+// A leading "gh pr <command>" is skipped. The repository given with -R or
+// --repo takes precedence over defaultRepo.
+func parseGHReference(fields []string, defaultRepo string) (*PullRequestURL, bool) {
+	if len(fields) > 2 && fields[0] == "gh" && fields[1] == "pr" {
+		fields = fields[3:]
+	}
+
+	var reference string
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		switch {
+		case field == "-R" || field == "--repo":
+			if i+1 == len(fields) {
+				return nil, false
+			}
+
+			i++
+			defaultRepo = fields[i]
+		case strings.HasPrefix(field, "--repo="):
+			defaultRepo = strings.TrimPrefix(field, "--repo=")
+		case reference == "" && !strings.HasPrefix(field, "-"):
+			reference = field
+		default:
+			return nil, false
+		}
+	}
+
+	if reference == "" || defaultRepo == "" {
+		return nil, false
+	}
+
+	return parsePullRequestReferenceInRepo(reference, defaultRepo)
+}
+
+// parseNumberInRepo resolves a bare pull request number against a
+// repository given as "owner/repo", "host/owner/repo" or a repository URL.
+func parseNumberInRepo(number string, repoReference string) (*PullRequestURL, bool) {
+	prNumber, ok := parsePositiveInt(number)
+	if !ok || repoReference == "" {
+		return nil, false
+	}
+
+	host, segments, err := splitGitHubURL(repoReference)
+	if err != nil || otherProviderHosts[host] || len(segments) != 2 {
+		return nil, false
+	}
+
+	owner, repo, ok := parseRepoSegments(segments)
+	if !ok {
+		return nil, false
+	}
+
+	return &PullRequestURL{
+		Host:     host,
+		Owner:    owner,
+		Repo:     repo,
+		PRNumber: prNumber,
+	}, true
+}
@@ -0,0 +1,132 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePullRequestURL_Variations(t *testing.T) {
+	want := &PullRequestURL{Host: "github.com", Owner: "owner", Repo: "repo", PRNumber: 12}
+
+	tests := []struct {
+		name  string
+		input string
+		want  *PullRequestURL
+	}{
+		{name: "Canonical", input: "https://github.com/owner/repo/pull/12", want: want},
+		{name: "Files tab", input: "https://github.com/owner/repo/pull/12/files", want: want},
+		{name: "Single commit", input: "https://github.com/owner/repo/pull/12/commits/0123abcd", want: want},
+		{name: "Trailing slash", input: "https://github.com/owner/repo/pull/12/", want: want},
+		{name: "Query string", input: "https://github.com/owner/repo/pull/12?w=1", want: want},
+		{name: "Fragment", input: "https://github.com/owner/repo/pull/12#discussion_r123", want: want},
+		{name: "Pulls", input: "https://github.com/owner/repo/pulls/12", want: want},
+		{name: "API URL", input: "https://api.github.com/repos/owner/repo/pulls/12", want: want},
+		{name: "No scheme", input: "github.com/owner/repo/pull/12", want: want},
+		{name: "WWW host", input: "https://www.github.com/owner/repo/pull/12", want: want},
+		{name: "Surrounding whitespace", input: "  https://github.com/owner/repo/pull/12\n", want: want},
+		{name: "No host", input: "owner/repo/pull/12", want: want},
+		{name: "Shorthand", input: "owner/repo#12", want: want},
+		{name: "Shorthand with host", input: "github.com/owner/repo#12", want: want},
+		{
+			name:  "Enterprise shorthand",
+			input: "GHE.example.com:8443/owner/repo#12",
+			want:  &PullRequestURL{Host: "ghe.example.com:8443", Owner: "owner", Repo: "repo", PRNumber: 12},
+		},
+		{
+			name:  "Enterprise API URL",
+			input: "https://ghe.example.com/api/v3/repos/owner/repo/pulls/12",
+			want:  &PullRequestURL{Host: "ghe.example.com", Owner: "owner", Repo: "repo", PRNumber: 12},
+		},
+		{name: "Diff form", input: "https://github.com/owner/repo/pull/12.diff", want: want},
+		{name: "Patch form", input: "https://github.com/owner/repo/pull/12.patch", want: want},
+		{name: "Commits tab", input: "https://github.com/owner/repo/pull/12/commits", want: want},
+		{name: "gh repo flag", input: "12 -R owner/repo", want: want},
+		{name: "gh repo flag with equals", input: "--repo=owner/repo #12", want: want},
+		{name: "gh command", input: "gh pr view 12 --repo owner/repo", want: want},
+		{
+			name:  "gh repo flag with host",
+			input: "#12 -R ghe.example.com/owner/repo",
+			want:  &PullRequestURL{Host: "ghe.example.com", Owner: "owner", Repo: "repo", PRNumber: 12},
+		},
+		{
+			name:  "Enterprise host with port and no scheme",
+			input: "ghe.example.com:8443/owner/repo/pull/12",
+			want:  &PullRequestURL{Host: "ghe.example.com:8443", Owner: "owner", Repo: "repo", PRNumber: 12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePullRequestURL(tt.input)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParsePullRequestURL_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"foo",
+		"https://github.com/owner/repo",
+		"https://github.com/owner/repo/issues/12",
+		"https://github.com/owner/repo/pull/abc",
+		"https://github.com/owner/repo/pull/0",
+		"https://github.com/owner/repo/pull/-1",
+		"ftp://github.com/owner/repo/pull/12",
+		"https:///owner/repo/pull/12",
+		"owner/repo#abc",
+		"own er/repo#12",
+		"group/owner/repo#12",
+		"https://github.com/owner/repo/pull/12/anything/else",
+		"https://github.com/owner/repo/pull/12/checks",
+		"https://github.com/owner/repo/pull/12/commits/not-a-sha",
+		"https://github.com/owner/repo/pull/12.diff/files",
+		"https://gitlab.com/owner/repo/pull/3",
+		"https://bitbucket.org/owner/repo/pull/3",
+		"gitlab.com/owner/repo#3",
+		"12",
+		"#12",
+		"12 -R",
+		"12 13 -R owner/repo",
+		"12 --web -R owner/repo",
+		"12 -R gitlab.com/owner/repo",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			got, err := ParsePullRequestURL(input)
+
+			require.ErrorIs(t, err, ErrInvalidURL)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestParsePullRequestReference(t *testing.T) {
+	want := &PullRequestURL{Host: "github.com", Owner: "owner", Repo: "repo", PRNumber: 12}
+
+	for _, input := range []string{"12", "#12", "https://github.com/owner/repo/pull/12", "owner/repo#12"} {
+		got, err := ParsePullRequestReference(input, "owner/repo")
+
+		require.NoError(t, err, input)
+		require.Equal(t, want, got, input)
+	}
+
+	got, err := ParsePullRequestReference("#12", "https://ghe.example.com/owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, &PullRequestURL{Host: "ghe.example.com", Owner: "owner", Repo: "repo", PRNumber: 12}, got)
+
+	got, err = ParsePullRequestReference("12 -R other/repo", "owner/repo")
+	require.NoError(t, err)
+	require.Equal(t, "other", got.Owner)
+
+	for _, defaultRepo := range []string{"", "owner", "owner/repo/extra", "gitlab.com/owner/repo"} {
+		got, err := ParsePullRequestReference("#12", defaultRepo)
+
+		require.ErrorIs(t, err, ErrInvalidURL, defaultRepo)
+		require.Nil(t, got)
+	}
+}
@@ -0,0 +1,307 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gitLabDiffsPerPage is the page size used when listing the diffs of a
+// merge request, which is the maximum allowed by the GitLab API.
+const gitLabDiffsPerPage = 100
+
+// gitLabPathRegex matches the characters GitLab allows in the name of a
+// group or project.
+var gitLabPathRegex = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// MergeRequestURL identifies a merge request of a GitLab project.
+type MergeRequestURL struct {
+	// Host is the host name of the GitLab instance, such as "gitlab.com".
+	Host string
+
+	// Project is the full path of the project, including any nested
+	// groups, such as "group/subgroup/project", or its numeric ID.
+	Project string
+
+	// IID is the number of the merge request within the project, as shown
+	// in its URL.
+	IID int
+}
+
+// gitLabChange is a single file of a merge request, as returned by the
+// diffs and changes endpoints of the GitLab API.
+type gitLabChange struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	AMode       string `json:"a_mode"`
+	BMode       string `json:"b_mode"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	TooLarge    bool   `json:"too_large"`
+	Collapsed   bool   `json:"collapsed"`
+}
+
+// ParseMergeRequestURL parses a GitLab merge request URL and returns the host, project path
+// and merge request number. The canonical URL format is
+// https://[host]/[group]/[project]/-/merge_requests/[iid], where [group] may contain any
+// number of nested subgroups. Sub-pages such as /diffs, .diff and .patch suffixes, the
+// legacy form without the "/-" segment, and API URLs such as
+// https://[host]/api/v4/projects/[group]%2F[project]/merge_requests/[iid] are accepted as well.
+//
+// Parameters:
+//   - mergeRequestURL: A string representing the URL of a GitLab merge request.
+//
+// Returns:
+//   - A pointer to a MergeRequestURL struct containing the extracted information.
+//   - An error matching ErrInvalidURL if the URL is not a valid merge request URL.
+//
+// Example:
+//
+//	mr, err := ParseMergeRequestURL("https://gitlab.example.com/group/sub/project/-/merge_requests/42")
+//	if err != nil {
+//	  // Handle error
+//	}
+//	source := &GitLabMergeRequestSource{Fetcher: fetcher, MergeRequest: mr}
+func ParseMergeRequestURL(mergeRequestURL string) (*MergeRequestURL, error) {
+	mr, ok := parseMergeRequestReference(mergeRequestURL)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not a merge request URL", ErrInvalidURL, mergeRequestURL)
+	}
+
+	return mr, nil
+}
+
+// parseMergeRequestReference parses the forms accepted by
+// ParseMergeRequestURL.
+func parseMergeRequestReference(reference string) (*MergeRequestURL, bool) {
+	host, segments, err := splitGitHubURL(reference)
+	if err != nil || host == "github.com" {
+		return nil, false
+	}
+
+	minProjectSegments := 2
+	if len(segments) > 3 && segments[0] == "api" && segments[1] == "v4" && segments[2] == "projects" {
+		// The API addresses projects by their ID or their URL-encoded
+		// path, whose "%2F" separators are decoded by url.Parse.
+		segments = segments[3:]
+		minProjectSegments = 1
+	}
+
+	i := 0
+	for i < len(segments) && segments[i] != "-" && segments[i] != "merge_requests" {
+		i++
+	}
+
+	project, rest := segments[:i], segments[i:]
+	if len(rest) > 0 && rest[0] == "-" {
+		rest = rest[1:]
+	}
+
+	if len(project) < minProjectSegments || len(rest) < 2 || rest[0] != "merge_requests" {
+		return nil, false
+	}
+
+	for _, segment := range project {
+		if !gitLabPathRegex.MatchString(segment) {
+			return nil, false
+		}
+	}
+
+	number := strings.TrimSuffix(strings.TrimSuffix(rest[1], ".diff"), ".patch")
+
+	iid, ok := parsePositiveInt(number)
+	if !ok {
+		return nil, false
+	}
+
+	return &MergeRequestURL{
+		Host:    host,
+		Project: strings.TrimSuffix(strings.Join(project, "/"), ".git"),
+		IID:     iid,
+	}, true
+}
+
+// GitLabMergeRequestSource is the DiffSource of a GitLab merge request. The diff is retrieved
+// from the GitLab REST API with the HTTP client, user agent, retry policy and size limit of
+// the Fetcher, along with the token set by WithGitLabToken, which is sent in the PRIVATE-TOKEN
+// header, and the base URL set by WithGitLabBaseURL, which defaults to https://[host]/api/v4/.
+// A client set with WithGitHubClient is not used.
+type GitLabMergeRequestSource struct {
+	// Fetcher holds the settings used to retrieve the diff. A nil Fetcher
+	// behaves like one created by NewFetcher without options.
+	Fetcher *Fetcher
+
+	// MergeRequest identifies the merge request.
+	MergeRequest *MergeRequestURL
+}
+
+// GitDiffs retrieves the diff of the merge request with Fetcher.MergeRequestGitDiffs.
+func (s *GitLabMergeRequestSource) GitDiffs(ctx context.Context, opts ParseOptions) ([]*GitDiff, error) {
+	return fetcherOrDefault(s.Fetcher).MergeRequestGitDiffs(ctx, s.MergeRequest, opts)
+}
+
+// MergeRequestGitDiffs retrieves the changes of a GitLab merge request and converts them into
+// one GitDiff per file, dropping the files matched by opts.IgnoreList. Every page of the
+// merge request diffs endpoint is retrieved; GitLab instances older than 15.7, which lack
+// that endpoint, are served from the merge request changes endpoint instead. GitLab omits
+// the diff of very large files; the GitDiff of such a file has its Truncated field set.
+//
+// Example:
+//
+//	mr := &MergeRequestURL{Host: "gitlab.com", Project: "group/project", IID: 42}
+//	gitDiffs, err := fetcher.MergeRequestGitDiffs(ctx, mr, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+func (f *Fetcher) MergeRequestGitDiffs(ctx context.Context, mr *MergeRequestURL, opts ParseOptions) ([]*GitDiff, error) {
+	ignoreList, err := compileIgnoreList(opts.IgnoreList)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := f.mergeRequestChanges(ctx, mr)
+	if err != nil {
+		return nil, err
+	}
+
+	var filteredList []*GitDiff
+
+	for _, change := range changes {
+		gitDiff := gitDiffFromGitLabChange(change, opts)
+
+		if matchCompiledIgnoreList(gitDiff, ignoreList) {
+			continue
+		}
+
+		filteredList = append(filteredList, gitDiff)
+	}
+
+	return filteredList, nil
+}
+
+// mergeRequestChanges retrieves every page of the diffs of a merge request,
+// falling back to the changes endpoint if the diffs endpoint does not exist.
+func (f *Fetcher) mergeRequestChanges(ctx context.Context, mr *MergeRequestURL) ([]*gitLabChange, error) {
+	baseURL := f.gitLabBaseURL(mr.Host)
+	mergeRequestPath := fmt.Sprintf("%sprojects/%s/merge_requests/%d", baseURL, url.PathEscape(mr.Project), mr.IID)
+
+	var changes []*gitLabChange
+
+	for page := "1"; page != ""; {
+		var pageChanges []*gitLabChange
+
+		query := url.Values{"page": {page}, "per_page": {strconv.Itoa(gitLabDiffsPerPage)}}
+
+		resp, err := f.getGitLabJSON(ctx, mergeRequestPath+"/diffs?"+query.Encode(), &pageChanges)
+		if errors.Is(err, ErrNotFound) && page == "1" {
+			var mergeRequest struct {
+				Changes []*gitLabChange `json:"changes"`
+			}
+
+			if _, err := f.getGitLabJSON(ctx, mergeRequestPath+"/changes", &mergeRequest); err != nil {
+				return nil, err
+			}
+
+			return mergeRequest.Changes, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, pageChanges...)
+		page = resp.Header.Get("X-Next-Page")
+	}
+
+	return changes, nil
+}
+
+// getGitLabJSON makes an authenticated GET request to the GitLab API and
+// decodes the JSON response into v, applying the Fetcher's size limit.
+func (f *Fetcher) getGitLabJSON(ctx context.Context, rawURL string, v any) (*http.Response, error) {
+	var header http.Header
+	if f.gitLabToken != "" {
+		header = http.Header{"PRIVATE-TOKEN": {f.gitLabToken}}
+	}
+
+	body, resp, err := f.openProviderURL(ctx, rawURL, "application/json", header)
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeBody(body)
+
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// gitLabBaseURL returns the base URL of the GitLab API, with a trailing
+// slash.
+func (f *Fetcher) gitLabBaseURL(host string) string {
+	if f.gitLabAPIURL != nil {
+		return f.gitLabAPIURL.String()
+	}
+
+	return "https://" + host + "/api/v4/"
+}
+
+// gitDiffFromGitLabChange builds the diff of a single file from the change
+// returned by the GitLab API with gitDiffFromPatch, adding the file modes
+// GitLab reports. GitLab omits the diff of binary files, which it replaces
+// by a "Binary files ... differ" line, and of very large files, which are
+// marked as truncated.
+func gitDiffFromGitLabChange(change *gitLabChange, opts ParseOptions) *GitDiff {
+	status := gitLabChangeStatus(change)
+
+	var gitDiff *GitDiff
+
+	if binary := strings.TrimRight(change.Diff, "\n"); strings.HasPrefix(binary, "Binary files ") {
+		gitDiff = gitDiffFromPatch(change.OldPath, change.NewPath, status, "", opts)
+		gitDiff.IsBinary = true
+		gitDiff.DiffContents = binary
+	} else {
+		gitDiff = gitDiffFromPatch(change.OldPath, change.NewPath, status, change.Diff, opts)
+		if gitDiff.DiffContents == "" {
+			gitDiff.Truncated = change.TooLarge || change.Collapsed
+		}
+	}
+
+	switch status {
+	case StatusAdded:
+		gitDiff.NewMode = change.BMode
+	case StatusDeleted:
+		gitDiff.OldMode = change.AMode
+	default:
+		gitDiff.OldMode, gitDiff.NewMode = change.AMode, change.BMode
+	}
+
+	return gitDiff
+}
+
+// gitLabChangeStatus derives the FileStatus of a change returned by the
+// GitLab API from its flags and modes.
+func gitLabChangeStatus(change *gitLabChange) FileStatus {
+	switch {
+	case change.NewFile:
+		return StatusAdded
+	case change.DeletedFile:
+		return StatusDeleted
+	case change.RenamedFile:
+		return StatusRenamed
+	case change.AMode != change.BMode:
+		return StatusModeChanged
+	default:
+		return StatusModified
+	}
+}
@@ -0,0 +1,250 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMergeRequestURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  *MergeRequestURL
+	}{
+		{
+			name:  "Project",
+			input: "https://gitlab.com/group/project/-/merge_requests/42",
+			want:  &MergeRequestURL{Host: "gitlab.com", Project: "group/project", IID: 42},
+		},
+		{
+			name:  "Nested groups",
+			input: "https://gitlab.example.com/group/sub/project/-/merge_requests/42",
+			want:  &MergeRequestURL{Host: "gitlab.example.com", Project: "group/sub/project", IID: 42},
+		},
+		{
+			name:  "Sub-page and fragment",
+			input: "https://gitlab.example.com/group/sub/project/-/merge_requests/42/diffs#note_1",
+			want:  &MergeRequestURL{Host: "gitlab.example.com", Project: "group/sub/project", IID: 42},
+		},
+		{
+			name:  "Diff suffix",
+			input: "gitlab.example.com/group/project/-/merge_requests/42.diff",
+			want:  &MergeRequestURL{Host: "gitlab.example.com", Project: "group/project", IID: 42},
+		},
+		{
+			name:  "Legacy",
+			input: "https://gitlab.example.com/group/project/merge_requests/7",
+			want:  &MergeRequestURL{Host: "gitlab.example.com", Project: "group/project", IID: 7},
+		},
+		{
+			name:  "API path",
+			input: "https://gitlab.example.com/api/v4/projects/group%2Fsub%2Fproject/merge_requests/42",
+			want:  &MergeRequestURL{Host: "gitlab.example.com", Project: "group/sub/project", IID: 42},
+		},
+		{
+			name:  "API ID",
+			input: "https://gitlab.example.com/api/v4/projects/1234/merge_requests/42/diffs",
+			want:  &MergeRequestURL{Host: "gitlab.example.com", Project: "1234", IID: 42},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMergeRequestURL(tt.input)
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseMergeRequestURL_Invalid(t *testing.T) {
+	tests := []string{
+		"",
+		"https://gitlab.com/project/-/merge_requests/42",
+		"https://gitlab.com/group/project/-/merge_requests",
+		"https://gitlab.com/group/project/-/merge_requests/0",
+		"https://gitlab.com/group/project/-/issues/42",
+		"https://github.com/owner/repo/pull/42",
+		"group/project/-/merge_requests/42",
+		"ftp://gitlab.com/group/project/-/merge_requests/42",
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			got, err := ParseMergeRequestURL(input)
+
+			require.ErrorIs(t, err, ErrInvalidURL)
+			require.Nil(t, got)
+		})
+	}
+}
+
+func TestMergeRequestGitDiffs(t *testing.T) {
+	var requests []string
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Header.Get("PRIVATE-TOKEN")+" "+r.URL.EscapedPath()+"?"+r.URL.RawQuery)
+
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("unexpected Authorization header %q", auth)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Header().Set("X-Next-Page", "2")
+			_, _ = w.Write([]byte(`[
+				{"old_path": "main.go", "new_path": "main.go", "a_mode": "100644", "b_mode": "100644",
+				 "diff": "@@ -1 +1 @@\n-a\n+b\n"},
+				{"old_path": "new.go", "new_path": "new.go", "a_mode": "0", "b_mode": "100644", "new_file": true,
+				 "diff": "@@ -0,0 +1 @@\n+c\n"}
+			]`))
+		case "2":
+			w.Header().Set("X-Next-Page", "")
+			_, _ = w.Write([]byte(`[
+				{"old_path": "old.go", "new_path": "renamed.go", "a_mode": "100644", "b_mode": "100644", "renamed_file": true,
+				 "diff": ""},
+				{"old_path": "gone.go", "new_path": "gone.go", "a_mode": "100644", "b_mode": "0", "deleted_file": true,
+				 "diff": "@@ -1 +0,0 @@\n-d\n"},
+				{"old_path": "huge.go", "new_path": "huge.go", "a_mode": "100644", "b_mode": "100644", "too_large": true,
+				 "diff": ""},
+				{"old_path": "go.sum", "new_path": "go.sum", "a_mode": "100644", "b_mode": "100644",
+				 "diff": "@@ -1 +1 @@\n-e\n+f\n"}
+			]`))
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}))
+	defer testServer.Close()
+
+	fetcher, err := NewFetcher(
+		WithGitLabBaseURL(testServer.URL+"/api/v4"),
+		WithGitLabToken("secret"),
+		WithBaseURL("https://ghe.example.com/api/v3"),
+		WithToken("github-token"),
+		WithHTTPClient(testServer.Client()),
+	)
+	require.NoError(t, err)
+
+	mr, err := ParseMergeRequestURL("https://gitlab.example.com/group/sub/project/-/merge_requests/42")
+	require.NoError(t, err)
+
+	gitDiffs, err := fetcher.MergeRequestGitDiffs(context.Background(), mr, ParseOptions{IgnoreList: []string{`\.sum$`}})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"secret /api/v4/projects/group%2Fsub%2Fproject/merge_requests/42/diffs?page=1&per_page=100",
+		"secret /api/v4/projects/group%2Fsub%2Fproject/merge_requests/42/diffs?page=2&per_page=100",
+	}, requests)
+	require.Len(t, gitDiffs, 5)
+
+	require.Equal(t, "main.go", gitDiffs[0].FilePathNew)
+	require.Equal(t, StatusModified, gitDiffs[0].Status)
+	require.Equal(t, "--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-a\n+b", gitDiffs[0].DiffContents)
+	require.Len(t, gitDiffs[0].Hunks, 1)
+
+	require.Equal(t, StatusAdded, gitDiffs[1].Status)
+	require.Equal(t, "", gitDiffs[1].OldMode)
+	require.Equal(t, "100644", gitDiffs[1].NewMode)
+	require.Equal(t, "--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+c", gitDiffs[1].DiffContents)
+
+	require.Equal(t, StatusRenamed, gitDiffs[2].Status)
+	require.Equal(t, "old.go", gitDiffs[2].FilePathOld)
+	require.Equal(t, "renamed.go", gitDiffs[2].FilePathNew)
+	require.False(t, gitDiffs[2].Truncated)
+
+	require.Equal(t, StatusDeleted, gitDiffs[3].Status)
+	require.Equal(t, "--- a/gone.go\n+++ /dev/null\n@@ -1 +0,0 @@\n-d", gitDiffs[3].DiffContents)
+
+	require.Equal(t, "huge.go", gitDiffs[4].FilePathNew)
+	require.True(t, gitDiffs[4].Truncated)
+}
+
+func TestMergeRequestGitDiffs_ChangesFallback(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/group%2Fproject/merge_requests/7/changes":
+			_, _ = w.Write([]byte(`{"iid": 7, "changes": [
+				{"old_path": "main.go", "new_path": "main.go", "a_mode": "100644", "b_mode": "100755", "diff": ""}
+			]}`))
+		default:
+			http.Error(w, `{"error": "404 Not Found"}`, http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	source := &GitLabMergeRequestSource{
+		Fetcher:      mustNewFetcher(t, WithGitLabBaseURL(testServer.URL+"/api/v4/"), WithHTTPClient(testServer.Client())),
+		MergeRequest: &MergeRequestURL{Host: "gitlab.example.com", Project: "group/project", IID: 7},
+	}
+
+	gitDiffs, err := source.GitDiffs(context.Background(), ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, StatusModeChanged, gitDiffs[0].Status)
+	require.Equal(t, "100755", gitDiffs[0].NewMode)
+
+	source.MergeRequest.IID = 8
+	_, err = source.GitDiffs(context.Background(), ParseOptions{})
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMergeRequestGitDiffs_Errors(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") == "" {
+			http.Error(w, `{"message": "401 Unauthorized"}`, http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`[{"old_path": "main.go", "new_path": "main.go", "diff": "@@ -1 +1 @@\n-a\n+b\n"}]`))
+	}))
+	defer testServer.Close()
+
+	mr := &MergeRequestURL{Host: "gitlab.example.com", Project: "group/project", IID: 1}
+
+	fetcher := mustNewFetcher(t, WithGitLabBaseURL(testServer.URL+"/api/v4/"), WithHTTPClient(testServer.Client()))
+	_, err := fetcher.MergeRequestGitDiffs(context.Background(), mr, ParseOptions{})
+	require.ErrorIs(t, err, ErrUnauthorized)
+
+	fetcher = mustNewFetcher(t, WithGitLabBaseURL(testServer.URL+"/api/v4/"), WithHTTPClient(testServer.Client()), WithGitLabToken("secret"), WithMaxBytes(10))
+	_, err = fetcher.MergeRequestGitDiffs(context.Background(), mr, ParseOptions{})
+	require.ErrorIs(t, err, ErrDiffTooLarge)
+
+	_, err = fetcher.MergeRequestGitDiffs(context.Background(), mr, ParseOptions{IgnoreList: []string{"("}})
+	require.Error(t, err)
+}
+
+func TestGitDiffFromGitLabChange_MaxFileBytes(t *testing.T) {
+	change := &gitLabChange{
+		OldPath: "big.go",
+		NewPath: "big.go",
+		Diff:    "@@ -1,2 +1,2 @@\n-" + strings.Repeat("a", 100) + "\n+" + strings.Repeat("b", 100) + "\n",
+	}
+
+	gitDiff := gitDiffFromGitLabChange(change, ParseOptions{MaxFileBytes: 150})
+
+	require.True(t, gitDiff.Truncated)
+	require.Equal(t, "--- a/big.go\n+++ b/big.go\n@@ -1,2 +1,2 @@\n-"+strings.Repeat("a", 100), gitDiff.DiffContents)
+	require.Len(t, gitDiff.Hunks[0].Lines, 1)
+
+	gitDiff = gitDiffFromGitLabChange(change, ParseOptions{})
+
+	require.False(t, gitDiff.Truncated)
+	require.Len(t, gitDiff.Hunks[0].Lines, 2)
+}
+
+func mustNewFetcher(t *testing.T, opts ...FetcherOption) *Fetcher {
+	t.Helper()
+
+	fetcher, err := NewFetcher(opts...)
+	require.NoError(t, err)
+
+	return fetcher
+}
@@ -0,0 +1,241 @@
+package github
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineType identifies the kind of a single line inside a hunk.
+type LineType int
+
+const (
+	// LineContext is an unchanged line, prefixed with a space in the diff.
+	LineContext LineType = iota
+
+	// LineAdded is a line that only exists in the new file, prefixed with "+".
+	LineAdded
+
+	// LineRemoved is a line that only exists in the old file, prefixed with "-".
+	LineRemoved
+)
+
+// String returns a human-readable name for the line type.
+func (t LineType) String() string {
+	switch t {
+	case LineContext:
+		return "context"
+	case LineAdded:
+		return "added"
+	case LineRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Hunk represents a single "@@ -a,b +c,d @@" section of a file diff.
+type Hunk struct {
+	// OldStart is the line number in the old file where the hunk begins.
+	OldStart int
+
+	// OldLines is the number of lines the hunk covers in the old file.
+	OldLines int
+
+	// NewStart is the line number in the new file where the hunk begins.
+	NewStart int
+
+	// NewLines is the number of lines the hunk covers in the new file.
+	NewLines int
+
+	// Section is the optional heading text that follows the second "@@",
+	// usually the enclosing function or type declaration.
+	Section string
+
+	// Lines contains the typed lines of the hunk in the order they appear.
+	Lines []*HunkLine
+}
+
+// HunkLine is a single context, added or removed line of a hunk.
+type HunkLine struct {
+	// Type indicates whether the line is context, an addition or a removal.
+	Type LineType
+
+	// Content is the text of the line without its leading "+", "-" or " ".
+	Content string
+
+	// OldLineNo is the line number in the old file, or 0 for added lines.
+	OldLineNo int
+
+	// NewLineNo is the line number in the new file, or 0 for removed lines.
+	NewLineNo int
+
+	// NoNewlineAtEOF is set when the line is followed by a
+	// "\ No newline at end of file" marker.
+	NoNewlineAtEOF bool
+}
+
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// ParseHunks parses the contents of a single file diff into a slice of Hunk
+// structs. It accepts the DiffContents of a GitDiff, or any text containing
+// unified diff hunks; lines before the first hunk header, such as the "---"
+// and "+++" file lines, are skipped.
+//
+// Each line of a hunk is assigned the old and new line numbers it occupies,
+// starting from the positions given in the hunk header. A
+// "\ No newline at end of file" marker is recorded on the preceding line
+// rather than being returned as a line of its own. A hunk ends once it holds
+// the number of old and new lines declared in its header; any text that
+// follows, such as blank separator lines or the "-- " signature appended by
+// git format-patch, is ignored up to the next hunk header.
+//
+// Parameters:
+//   - diffContents: A string containing one or more unified diff hunks.
+//
+// Returns:
+//   - A slice of Hunk structs, in the order they appear in the input.
+//   - An error if a hunk header is malformed.
+//
+// Example:
+//
+//	hunks, err := ParseHunks(gitDiff.DiffContents)
+//	if err != nil {
+//	  // Handle error
+//	}
+//	for _, hunk := range hunks {
+//	  // Use hunk.NewStart, hunk.Lines, etc.
+//	}
+func ParseHunks(diffContents string) ([]*Hunk, error) {
+	var (
+		hunks   []*Hunk
+		current *Hunk
+		oldLine int
+		newLine int
+		oldLeft int
+		newLeft int
+	)
+
+	for _, line := range strings.Split(diffContents, "\n") {
+		if strings.HasPrefix(line, "@@ ") {
+			hunk, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+
+			hunks = append(hunks, hunk)
+			current = hunk
+			oldLine, newLine = hunk.OldStart, hunk.NewStart
+			oldLeft, newLeft = hunk.OldLines, hunk.NewLines
+
+			continue
+		}
+
+		// Anything before the first hunk header is file metadata.
+		if current == nil {
+			continue
+		}
+
+		// The marker may follow the last line of a hunk.
+		if strings.HasPrefix(line, "\\") {
+			if n := len(current.Lines); n > 0 {
+				current.Lines[n-1].NoNewlineAtEOF = true
+			}
+
+			continue
+		}
+
+		if oldLeft <= 0 && newLeft <= 0 {
+			continue
+		}
+
+		hunkLine := &HunkLine{}
+
+		switch {
+		case strings.HasPrefix(line, "+"):
+			hunkLine.Type = LineAdded
+			hunkLine.Content = line[1:]
+			hunkLine.NewLineNo = newLine
+			newLine++
+			newLeft--
+		case strings.HasPrefix(line, "-"):
+			hunkLine.Type = LineRemoved
+			hunkLine.Content = line[1:]
+			hunkLine.OldLineNo = oldLine
+			oldLine++
+			oldLeft--
+		case strings.HasPrefix(line, " "), line == "":
+			// Some tools strip the trailing space from empty context lines.
+			hunkLine.Type = LineContext
+			hunkLine.Content = strings.TrimPrefix(line, " ")
+			hunkLine.OldLineNo = oldLine
+			hunkLine.NewLineNo = newLine
+			oldLine++
+			newLine++
+			oldLeft--
+			newLeft--
+		default:
+			continue
+		}
+
+		current.Lines = append(current.Lines, hunkLine)
+	}
+
+	return hunks, nil
+}
+
+// parseHunkHeader parses a "@@ -a,b +c,d @@ section" line into an empty
+// Hunk. Omitted line counts default to 1, as specified by the unified
+// diff format.
+func parseHunkHeader(line string) (*Hunk, error) {
+	matches := hunkHeaderRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, errors.New("invalid hunk header")
+	}
+
+	hunk := &Hunk{
+		OldStart: atoiOrDefault(matches[1], 0),
+		OldLines: atoiOrDefault(matches[2], 1),
+		NewStart: atoiOrDefault(matches[3], 0),
+		NewLines: atoiOrDefault(matches[4], 1),
+		Section:  matches[5],
+	}
+
+	return hunk, nil
+}
+
+// countHunkLines returns the number of lines a set of hunk lines occupies
+// in the old and new file respectively.
+func countHunkLines(lines []*HunkLine) (int, int) {
+	var oldCount, newCount int
+
+	for _, line := range lines {
+		switch line.Type {
+		case LineContext:
+			oldCount++
+			newCount++
+		case LineAdded:
+			newCount++
+		case LineRemoved:
+			oldCount++
+		}
+	}
+
+	return oldCount, newCount
+}
+
+// atoiOrDefault converts s to an integer, returning def if s is empty.
+// The hunk header regex guarantees that non-empty values are numeric.
+func atoiOrDefault(s string, def int) int {
+	if s == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+
+	return n
+}
@@ -0,0 +1,96 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHunks(t *testing.T) {
+	diffContents := `--- a/main.go
++++ b/main.go
+@@ -10,4 +10,5 @@ func main() {
+ 	fmt.Println("a")
+-	fmt.Println("b")
++	fmt.Println("c")
++	fmt.Println("d")
+ 	fmt.Println("e")
+@@ -30 +31,0 @@ func helper() {
+-	return
+\ No newline at end of file`
+
+	hunks, err := ParseHunks(diffContents)
+
+	require.NoError(t, err)
+	require.Len(t, hunks, 2)
+
+	require.Equal(t, &Hunk{
+		OldStart: 10,
+		OldLines: 4,
+		NewStart: 10,
+		NewLines: 5,
+		Section:  "func main() {",
+		Lines: []*HunkLine{
+			{Type: LineContext, Content: "\tfmt.Println(\"a\")", OldLineNo: 10, NewLineNo: 10},
+			{Type: LineRemoved, Content: "\tfmt.Println(\"b\")", OldLineNo: 11},
+			{Type: LineAdded, Content: "\tfmt.Println(\"c\")", NewLineNo: 11},
+			{Type: LineAdded, Content: "\tfmt.Println(\"d\")", NewLineNo: 12},
+			{Type: LineContext, Content: "\tfmt.Println(\"e\")", OldLineNo: 12, NewLineNo: 13},
+		},
+	}, hunks[0])
+
+	require.Equal(t, &Hunk{
+		OldStart: 30,
+		OldLines: 1,
+		NewStart: 31,
+		NewLines: 0,
+		Section:  "func helper() {",
+		Lines: []*HunkLine{
+			{Type: LineRemoved, Content: "\treturn", OldLineNo: 30, NoNewlineAtEOF: true},
+		},
+	}, hunks[1])
+}
+
+func TestParseHunks_NoHunks(t *testing.T) {
+	hunks, err := ParseHunks("--- a/file.txt\n+++ b/file.txt")
+
+	require.NoError(t, err)
+	require.Empty(t, hunks)
+}
+
+func TestParseHunks_EmptyContextLine(t *testing.T) {
+	hunks, err := ParseHunks("@@ -1,3 +1,3 @@\n a\n\n-b\n+c\n")
+
+	require.NoError(t, err)
+	require.Len(t, hunks, 1)
+	require.Len(t, hunks[0].Lines, 4)
+	require.Equal(t, &HunkLine{Type: LineContext, OldLineNo: 2, NewLineNo: 2}, hunks[0].Lines[1])
+}
+
+func TestParseHunks_StopsAtDeclaredCounts(t *testing.T) {
+	hunks, err := ParseHunks("@@ -1 +1 @@\n-a\n+b\n\\ No newline at end of file\n-- \n2.39.5\n\n@@ -5,2 +5 @@\n c\n-- \n")
+
+	require.NoError(t, err)
+	require.Len(t, hunks, 2)
+	require.Equal(t, []*HunkLine{
+		{Type: LineRemoved, Content: "a", OldLineNo: 1},
+		{Type: LineAdded, Content: "b", NewLineNo: 1, NoNewlineAtEOF: true},
+	}, hunks[0].Lines)
+	require.Equal(t, []*HunkLine{
+		{Type: LineContext, Content: "c", OldLineNo: 5, NewLineNo: 5},
+		{Type: LineRemoved, Content: "- ", OldLineNo: 6},
+	}, hunks[1].Lines)
+}
+
+func TestParseHunks_InvalidHeader(t *testing.T) {
+	hunks, err := ParseHunks("@@ -a,b +c,d @@\n+line")
+
+	require.Error(t, err)
+	require.Nil(t, hunks)
+}
+
+func TestLineTypeString(t *testing.T) {
+	require.Equal(t, "context", LineContext.String())
+	require.Equal(t, "added", LineAdded.String())
+	require.Equal(t, "removed", LineRemoved.String())
+}
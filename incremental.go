@@ -0,0 +1,331 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// IncrementalDiff is the part of a pull request diff that changed since a previously
+// reviewed head commit, as returned by GetPullRequestIncrementalDiff.
+type IncrementalDiff struct {
+	// PullRequest contains the current details of the pull request.
+	PullRequest *github.PullRequest
+
+	// SinceSHA is the previously reviewed head commit.
+	SinceSHA string
+
+	// HeadSHA is the current head commit of the pull request.
+	HeadSHA string
+
+	// ForcePushed is true if SinceSHA is no longer an ancestor of HeadSHA,
+	// because the branch was rebased, amended or reset since the review.
+	ForcePushed bool
+
+	// Full is true if SinceSHA could not be found in the repository, which
+	// happens once a force-pushed commit has been garbage collected. Files
+	// then contains the whole diff of the pull request.
+	Full bool
+
+	// Files contains the parsed and non-ignored file diffs that changed
+	// since SinceSHA. Without a force-push, this is the diff between
+	// SinceSHA and HeadSHA. After a force-push, it contains the file diffs
+	// of the current pull request whose changes differ from the ones at
+	// SinceSHA, so files only touched by the rebase are left out.
+	Files []*GitDiff
+
+	// Reverted contains the file diffs the pull request had at SinceSHA for
+	// files it no longer changes. It is only set when ForcePushed is true.
+	Reverted []*GitDiff
+}
+
+// GitHubCompareCommitsClientInterface extends GitHubClientInterface with the ability to
+// compare two commits of a repository, as used by GetPullRequestIncrementalDiff to find
+// out whether the branch of a pull request was force-pushed.
+type GitHubCompareCommitsClientInterface interface {
+	GitHubClientInterface
+
+	// CompareCommits compares the base and head commits of a repository. The Status of the
+	// returned comparison is "identical", "ahead", "behind" or "diverged".
+	CompareCommits(
+		ctx context.Context,
+		owner string,
+		repo string,
+		base string,
+		head string,
+		opts *github.ListOptions,
+	) (*github.CommitsComparison, *github.Response, error)
+}
+
+// CompareCommits compares two commits using the Repositories service of the wrapped client.
+func (c *GitHubClientWrapper) CompareCommits(
+	ctx context.Context,
+	owner string,
+	repo string,
+	base string,
+	head string,
+	opts *github.ListOptions,
+) (*github.CommitsComparison, *github.Response, error) {
+	return c.Repositories.CompareCommits(ctx, owner, repo, base, head, opts)
+}
+
+// CompareCommits calls the mock implementation of the CompareCommits method. If
+// MockCompareCommits is set to a custom function, that function is executed and its result
+// returned. If MockCompareCommits is not set, the method returns an error.
+func (m *MockGitClient) CompareCommits(
+	ctx context.Context,
+	owner string,
+	repo string,
+	base string,
+	head string,
+	opts *github.ListOptions,
+) (*github.CommitsComparison, *github.Response, error) {
+	if m.MockCompareCommits != nil {
+		return m.MockCompareCommits(ctx, owner, repo, base, head, opts)
+	}
+
+	return nil, nil, errMockNotSet("MockCompareCommits")
+}
+
+// GetPullRequestIncrementalDiff retrieves only the changes a pull request received since a
+// previously reviewed head commit, so that a reviewer does not have to look at the whole diff
+// again after new commits are pushed. It is equivalent to calling Fetcher.IncrementalDiff on
+// a Fetcher created with WithGitHubClient(client).
+//
+// If the previously reviewed commit is an ancestor of the current head, the result is the
+// diff between the two commits. If the branch was force-pushed, for example after a rebase,
+// that diff would also contain every change made to the base branch in the meantime. The
+// function then compares the diff the pull request had at the reviewed commit with its
+// current diff file by file, in the manner of git range-diff, and returns the current file
+// diffs whose added and removed lines differ. Line number shifts caused by the rebase are
+// ignored. Files the pull request no longer changes are returned in Reverted.
+//
+// Parameters:
+//   - ctx: A context.Context object, used for managing the lifecycle of the requests.
+//   - pr: A pointer to a PullRequestURL struct, containing the owner, repository, and pull request number.
+//   - sinceSHA: The head commit of the pull request at the time of the previous review.
+//   - client: An implementation of the GitHubClientInterface that also implements
+//     GitHubCompareClientInterface and GitHubCompareCommitsClientInterface, such as
+//     GitHubClientWrapper.
+//   - opts: A ParseOptions struct containing the ignore list and other parsing settings.
+//
+// Returns:
+//   - A pointer to an IncrementalDiff struct containing the changed file diffs.
+//   - An error if any of the requests fail, or a *ParseError if some file diffs could not be
+//     parsed, in which case the IncrementalDiff is returned with the remaining file diffs.
+//
+// Example:
+//
+//	incremental, err := GetPullRequestIncrementalDiff(ctx, prURL, lastReviewedSHA, ghClient, ParseOptions{})
+//	if err != nil {
+//	  // Handle error
+//	}
+//	for _, gitDiff := range incremental.Files {
+//	  // Review only what changed since lastReviewedSHA
+//	}
+//	lastReviewedSHA = incremental.HeadSHA
+func GetPullRequestIncrementalDiff(
+	ctx context.Context,
+	pr *PullRequestURL,
+	sinceSHA string,
+	client GitHubClientInterface,
+	opts ParseOptions,
+) (*IncrementalDiff, error) {
+	fetcher, err := NewFetcher(WithGitHubClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.IncrementalDiff(ctx, pr, sinceSHA, opts)
+}
+
+// IncrementalDiff retrieves the changes a pull request received since sinceSHA, as described
+// for GetPullRequestIncrementalDiff.
+func (f *Fetcher) IncrementalDiff(
+	ctx context.Context,
+	pr *PullRequestURL,
+	sinceSHA string,
+	opts ParseOptions,
+) (*IncrementalDiff, error) {
+	client, err := f.gitHubClient(pr.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	compareClient, ok := client.(GitHubCompareCommitsClientInterface)
+	if !ok {
+		return nil, errors.New("client does not implement GitHubCompareCommitsClientInterface")
+	}
+
+	pullRequest, err := f.PullRequest(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	incremental := &IncrementalDiff{
+		PullRequest: pullRequest,
+		SinceSHA:    sinceSHA,
+		HeadSHA:     pullRequest.GetHead().GetSHA(),
+	}
+
+	if incremental.HeadSHA == "" {
+		return nil, errors.New("pull request has no head commit")
+	}
+
+	if incremental.HeadSHA == sinceSHA {
+		return incremental, nil
+	}
+
+	// Only the status of the comparison is needed, so a single commit is
+	// requested to keep the response small.
+	comparison, resp, err := compareClient.CompareCommits(ctx, pr.Owner, pr.Repo, sinceSHA, incremental.HeadSHA, &github.ListOptions{PerPage: 1})
+
+	switch err = wrapGitHubError(resp, err); {
+	case errors.Is(err, ErrNotFound):
+		incremental.ForcePushed = true
+		incremental.Full = true
+
+		var diff string
+
+		diff, err = f.Diff(ctx, pr)
+		if err == nil {
+			incremental.Files, err = ParseGitDiffE(diff, opts)
+		}
+	case err != nil:
+		return nil, err
+	default:
+		switch status := comparison.GetStatus(); status {
+		case "identical":
+		case "ahead":
+			cmp := &CompareURL{Host: pr.Host, Owner: pr.Owner, Repo: pr.Repo, Base: sinceSHA, Head: incremental.HeadSHA}
+
+			incremental.Files, err = f.CompareGitDiffs(ctx, cmp, opts)
+		case "behind", "diverged":
+			incremental.ForcePushed = true
+
+			err = f.rangeDiff(ctx, pr, incremental, opts)
+		default:
+			return nil, fmt.Errorf("unexpected comparison status %q", status)
+		}
+	}
+
+	var parseErr *ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+		return nil, err
+	}
+
+	return incremental, err
+}
+
+// rangeDiff fills in the Files and Reverted fields of a force-pushed
+// IncrementalDiff by comparing the diff the pull request had at SinceSHA
+// with its current diff, file by file.
+func (f *Fetcher) rangeDiff(ctx context.Context, pr *PullRequestURL, incremental *IncrementalDiff, opts ParseOptions) error {
+	base := incremental.PullRequest.GetBase().GetSHA()
+	if base == "" {
+		base = incremental.PullRequest.GetBase().GetRef()
+	}
+
+	var skipped []*ChunkError
+
+	// The three-dot comparison diffs SinceSHA against its merge base with
+	// the base branch, which is the diff the pull request had at the time.
+	before, err := f.CompareGitDiffs(ctx, &CompareURL{Host: pr.Host, Owner: pr.Owner, Repo: pr.Repo, Base: base, Head: incremental.SinceSHA}, opts)
+	if err = collectChunkErrors(err, &skipped); err != nil {
+		return err
+	}
+
+	diff, err := f.Diff(ctx, pr)
+	if err != nil {
+		return err
+	}
+
+	after, err := ParseGitDiffE(diff, opts)
+	if err = collectChunkErrors(err, &skipped); err != nil {
+		return err
+	}
+
+	previous := make(map[string]*GitDiff, len(before))
+	for _, gitDiff := range before {
+		previous[gitDiffPath(gitDiff)] = gitDiff
+	}
+
+	for _, gitDiff := range after {
+		path := gitDiffPath(gitDiff)
+
+		old, ok := previous[path]
+		if !ok || gitDiffChanges(old) != gitDiffChanges(gitDiff) {
+			incremental.Files = append(incremental.Files, gitDiff)
+		}
+
+		delete(previous, path)
+	}
+
+	for _, gitDiff := range before {
+		if _, ok := previous[gitDiffPath(gitDiff)]; ok {
+			incremental.Reverted = append(incremental.Reverted, gitDiff)
+		}
+	}
+
+	if len(skipped) > 0 {
+		return &ParseError{Chunks: skipped}
+	}
+
+	return nil
+}
+
+// collectChunkErrors appends the chunks of a *ParseError to skipped and
+// returns any other error unchanged.
+func collectChunkErrors(err error, skipped *[]*ChunkError) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		*skipped = append(*skipped, parseErr.Chunks...)
+
+		return nil
+	}
+
+	return err
+}
+
+// gitDiffPath returns the path that identifies a file diff, which is the
+// new path unless the file was deleted.
+func gitDiffPath(gitDiff *GitDiff) string {
+	if gitDiff.Status == StatusDeleted {
+		return gitDiff.FilePathOld
+	}
+
+	return gitDiff.FilePathNew
+}
+
+// gitDiffChanges summarizes what a file diff changes, ignoring context
+// lines and line numbers, so that the same change rebased onto another
+// base compares equal.
+func gitDiffChanges(gitDiff *GitDiff) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s %s %s %s %s\n", gitDiff.Status, gitDiff.FilePathOld, gitDiff.FilePathNew, gitDiff.OldMode, gitDiff.NewMode)
+
+	if len(gitDiff.Hunks) == 0 {
+		// Binary and header-only diffs are compared by their blob hashes,
+		// which only depend on the file contents.
+		sb.WriteString(gitDiff.Index)
+
+		return sb.String()
+	}
+
+	for _, hunk := range gitDiff.Hunks {
+		for _, line := range hunk.Lines {
+			switch line.Type {
+			case LineAdded:
+				sb.WriteString("+" + line.Content + "\n")
+			case LineRemoved:
+				sb.WriteString("-" + line.Content + "\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
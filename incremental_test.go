@@ -0,0 +1,164 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	incrementalMainBefore = "diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n@@ -1,2 +1,2 @@\n ctx\n-a\n+b\n"
+	incrementalMainMoved  = "diff --git a/main.go b/main.go\nindex 3..4 100644\n--- a/main.go\n+++ b/main.go\n@@ -10,2 +10,2 @@\n other\n-a\n+b\n"
+	incrementalUtilBefore = "diff --git a/util.go b/util.go\nindex 5..6 100644\n--- a/util.go\n+++ b/util.go\n@@ -1 +1 @@\n-c\n+d\n"
+	incrementalUtilAfter  = "diff --git a/util.go b/util.go\nindex 5..7 100644\n--- a/util.go\n+++ b/util.go\n@@ -1 +1 @@\n-c\n+e\n"
+	incrementalOldFile    = "diff --git a/old.go b/old.go\nindex 8..9 100644\n--- a/old.go\n+++ b/old.go\n@@ -1 +1 @@\n-x\n+y\n"
+	incrementalNewFile    = "diff --git a/new.go b/new.go\nnew file mode 100644\nindex 0000000..a\n--- /dev/null\n+++ b/new.go\n@@ -0,0 +1 @@\n+z\n"
+)
+
+// newIncrementalMock returns a mock for a pull request whose head is "head"
+// and whose base is "base", comparing the given status against sinceSHA.
+func newIncrementalMock(t *testing.T, status string, compareErr error, currentDiff string, rawDiffs map[string]string) *MockGitClient {
+	t.Helper()
+
+	return &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{
+				Head: &github.PullRequestBranch{SHA: github.String("head")},
+				Base: &github.PullRequestBranch{SHA: github.String("base"), Ref: github.String("main")},
+			}, nil, nil
+		},
+		MockGetRaw: func(ctx context.Context, owner, repo string, number int, opts github.RawOptions) (io.ReadCloser, *github.Response, error) {
+			return io.NopCloser(strings.NewReader(currentDiff)), nil, nil
+		},
+		MockCompareCommits: func(
+			ctx context.Context,
+			owner, repo, base, head string,
+			opts *github.ListOptions,
+		) (*github.CommitsComparison, *github.Response, error) {
+			require.Equal(t, "since", base)
+			require.Equal(t, "head", head)
+
+			if compareErr != nil {
+				return nil, &github.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}, compareErr
+			}
+
+			return &github.CommitsComparison{Status: github.String(status)}, nil, nil
+		},
+		MockCompareRaw: func(
+			ctx context.Context,
+			owner, repo, base, head string,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			diff, ok := rawDiffs[base+"..."+head]
+			require.True(t, ok, base+"..."+head)
+
+			return io.NopCloser(strings.NewReader(diff)), nil, nil
+		},
+	}
+}
+
+func TestGetPullRequestIncrementalDiff_FastForward(t *testing.T) {
+	mockClient := newIncrementalMock(t, "ahead", nil, "", map[string]string{
+		"since...head": incrementalUtilAfter,
+	})
+
+	incremental, err := GetPullRequestIncrementalDiff(context.Background(), &PullRequestURL{Owner: "owner", Repo: "repo", PRNumber: 1}, "since", mockClient, ParseOptions{})
+	require.NoError(t, err)
+	require.False(t, incremental.ForcePushed)
+	require.False(t, incremental.Full)
+	require.Equal(t, "since", incremental.SinceSHA)
+	require.Equal(t, "head", incremental.HeadSHA)
+	require.Len(t, incremental.Files, 1)
+	require.Equal(t, "util.go", incremental.Files[0].FilePathNew)
+	require.Empty(t, incremental.Reverted)
+}
+
+func TestGetPullRequestIncrementalDiff_Unchanged(t *testing.T) {
+	for _, since := range []string{"head", "since"} {
+		t.Run(since, func(t *testing.T) {
+			mockClient := newIncrementalMock(t, "identical", nil, "", nil)
+
+			incremental, err := GetPullRequestIncrementalDiff(context.Background(), &PullRequestURL{Owner: "owner", Repo: "repo", PRNumber: 1}, since, mockClient, ParseOptions{})
+			require.NoError(t, err)
+			require.False(t, incremental.ForcePushed)
+			require.Empty(t, incremental.Files)
+		})
+	}
+}
+
+func TestGetPullRequestIncrementalDiff_ForcePushed(t *testing.T) {
+	for _, status := range []string{"diverged", "behind"} {
+		t.Run(status, func(t *testing.T) {
+			mockClient := newIncrementalMock(t, status, nil,
+				incrementalMainMoved+incrementalUtilAfter+incrementalNewFile,
+				map[string]string{
+					"base...since": incrementalMainBefore + incrementalUtilBefore + incrementalOldFile,
+				},
+			)
+
+			incremental, err := GetPullRequestIncrementalDiff(context.Background(), &PullRequestURL{Owner: "owner", Repo: "repo", PRNumber: 1}, "since", mockClient, ParseOptions{})
+			require.NoError(t, err)
+			require.True(t, incremental.ForcePushed)
+			require.False(t, incremental.Full)
+
+			// main.go only moved because of the rebase, so it is left out.
+			require.Len(t, incremental.Files, 2)
+			require.Equal(t, "util.go", incremental.Files[0].FilePathNew)
+			require.Equal(t, "new.go", incremental.Files[1].FilePathNew)
+
+			require.Len(t, incremental.Reverted, 1)
+			require.Equal(t, "old.go", incremental.Reverted[0].FilePathNew)
+		})
+	}
+}
+
+func TestGetPullRequestIncrementalDiff_SinceNotFound(t *testing.T) {
+	mockClient := newIncrementalMock(t, "", errors.New("No commit found for SHA: since"), incrementalMainMoved+incrementalUtilAfter, nil)
+
+	incremental, err := GetPullRequestIncrementalDiff(context.Background(), &PullRequestURL{Owner: "owner", Repo: "repo", PRNumber: 1}, "since", mockClient, ParseOptions{})
+	require.NoError(t, err)
+	require.True(t, incremental.ForcePushed)
+	require.True(t, incremental.Full)
+	require.Len(t, incremental.Files, 2)
+}
+
+func TestGetPullRequestIncrementalDiff_Errors(t *testing.T) {
+	pr := &PullRequestURL{Owner: "owner", Repo: "repo", PRNumber: 1}
+
+	_, err := GetPullRequestIncrementalDiff(context.Background(), pr, "since", newIncrementalMock(t, "unknown", nil, "", nil), ParseOptions{})
+	require.EqualError(t, err, `unexpected comparison status "unknown"`)
+
+	_, err = GetPullRequestIncrementalDiff(context.Background(), pr, "since", &clientWithoutRaw{mock: &MockGitClient{}}, ParseOptions{})
+	require.EqualError(t, err, "client does not implement GitHubCompareCommitsClientInterface")
+
+	_, err = GetPullRequestIncrementalDiff(context.Background(), pr, "since", &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{}, nil, nil
+		},
+	}, ParseOptions{})
+	require.EqualError(t, err, "pull request has no head commit")
+}
+
+func TestCompareCommits_Wrapper(t *testing.T) {
+	var requestURL string
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURL = r.URL.String()
+
+		_, _ = w.Write([]byte(`{"status": "diverged", "ahead_by": 2, "behind_by": 1}`))
+	}))
+	defer testServer.Close()
+
+	comparison, _, err := newTestGitHubClient(t, testServer, "").CompareCommits(context.Background(), "owner", "repo", "since", "head", &github.ListOptions{PerPage: 1})
+	require.NoError(t, err)
+	require.Equal(t, "/repos/owner/repo/compare/since...head?per_page=1", requestURL)
+	require.Equal(t, "diverged", comparison.GetStatus())
+	require.Equal(t, 2, comparison.GetAheadBy())
+}
@@ -0,0 +1,177 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// LocalDiffMode selects what a LocalSource compares.
+type LocalDiffMode int
+
+const (
+	// LocalModeRange compares two commits of the repository in the same way
+	// as a pull request, with "git diff base...head": the changes made on
+	// Head since it diverged from Base.
+	LocalModeRange LocalDiffMode = iota
+
+	// LocalModeStaged compares the index with Base, or with HEAD if Base is
+	// empty, as "git diff --cached" does. It contains the changes that would
+	// be committed next.
+	LocalModeStaged
+
+	// LocalModeWorkingTree compares the working tree with Base, as
+	// "git diff base" does, or with the index if Base is empty, as
+	// "git diff" does. Untracked files are not included.
+	LocalModeWorkingTree
+)
+
+// LocalSource produces diffs from a local Git repository by running git diff in its working
+// directory, for example in a pre-commit or pre-push hook. The diffs are parsed in the same way
+// as the diffs retrieved from GitHub.
+type LocalSource struct {
+	// Dir is the directory of the repository, or of any directory inside
+	// it. An empty Dir uses the current directory.
+	Dir string
+
+	// Mode selects what is compared.
+	Mode LocalDiffMode
+
+	// Base is the commit, branch or tag the comparison starts from. It is
+	// required for LocalModeRange and optional otherwise.
+	Base string
+
+	// Head is the commit, branch or tag the comparison ends at in
+	// LocalModeRange. An empty Head means "HEAD". It must be empty in the
+	// other modes.
+	Head string
+
+	// GitPath is the path of the git executable. An empty GitPath looks up
+	// "git" in the PATH.
+	GitPath string
+}
+
+// Diff runs git diff in the repository and returns its output.
+//
+// Parameters:
+//   - ctx: A context.Context object. Cancelling it kills the git process.
+//
+// Returns:
+//   - A string containing the Git diff, which is empty if nothing changed.
+//   - An error if the source is misconfigured or git fails, for example because Dir is not
+//     inside a repository or a ref does not exist. The error includes what git printed to
+//     its standard error.
+//
+// Example:
+//
+//	source := &LocalSource{Dir: ".", Mode: LocalModeRange, Base: "origin/main"}
+//	diff, err := source.Diff(ctx)
+//	if err != nil {
+//	  // Handle error
+//	}
+func (s *LocalSource) Diff(ctx context.Context) (string, error) {
+	args, err := s.diffArgs()
+	if err != nil {
+		return "", err
+	}
+
+	gitPath := s.GitPath
+	if gitPath == "" {
+		gitPath = "git"
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, gitPath, args...)
+	cmd.Dir = s.Dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git diff failed: %w: %s", err, message)
+		}
+
+		return "", fmt.Errorf("git diff failed: %w", err)
+	}
+
+	return stdout.String(), nil
+}
+
+// GitDiffs runs git diff in the repository with Diff and parses its output with
+// ParseGitDiffE, applying the same ignore list and other parsing settings as the
+// functions that retrieve diffs from GitHub.
+//
+// Example:
+//
+//	source := &LocalSource{Dir: repoDir, Mode: LocalModeStaged}
+//	gitDiffs, err := source.GitDiffs(ctx, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+func (s *LocalSource) GitDiffs(ctx context.Context, opts ParseOptions) ([]*GitDiff, error) {
+	if _, err := compileIgnoreList(opts.IgnoreList); err != nil {
+		return nil, err
+	}
+
+	diff, err := s.Diff(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseGitDiffE(diff, opts)
+}
+
+// diffArgs returns the git arguments for the configured mode. The options
+// override the configuration settings that change the output format, such
+// as diff.noprefix, color.diff and diff.external, so that the output can
+// always be parsed.
+func (s *LocalSource) diffArgs() ([]string, error) {
+	for _, ref := range []string{s.Base, s.Head} {
+		if strings.HasPrefix(ref, "-") {
+			return nil, fmt.Errorf("invalid ref %q", ref)
+		}
+	}
+
+	args := []string{
+		"diff",
+		"--no-color",
+		"--no-ext-diff",
+		"--no-textconv",
+		"--src-prefix=a/",
+		"--dst-prefix=b/",
+	}
+
+	switch s.Mode {
+	case LocalModeRange:
+		if s.Base == "" {
+			return nil, errors.New("a base ref is required to diff a range")
+		}
+
+		head := s.Head
+		if head == "" {
+			head = "HEAD"
+		}
+
+		return append(args, s.Base+"..."+head, "--"), nil
+	case LocalModeStaged, LocalModeWorkingTree:
+		if s.Head != "" {
+			return nil, errors.New("a head ref can only be used to diff a range")
+		}
+
+		if s.Mode == LocalModeStaged {
+			args = append(args, "--cached")
+		}
+
+		if s.Base != "" {
+			args = append(args, s.Base)
+		}
+
+		return append(args, "--"), nil
+	default:
+		return nil, fmt.Errorf("unknown local diff mode %d", s.Mode)
+	}
+}
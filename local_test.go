@@ -0,0 +1,156 @@
+package github
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepository creates a repository with a "main" branch holding
+// main.go and README.md, and a "feature" branch that changes main.go and
+// adds go.sum. The "feature" branch is checked out.
+func newTestRepository(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	writeTestFile(t, dir, "main.go", "package main\n")
+	writeTestFile(t, dir, "README.md", "# Test\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "--message=Initial commit")
+
+	runGit(t, dir, "checkout", "--quiet", "-b", "feature")
+	writeTestFile(t, dir, "main.go", "package main\n\nfunc main() {}\n")
+	writeTestFile(t, dir, "go.sum", "checksum\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "--message=Add main")
+
+	return dir
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Test",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+	)
+
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func writeTestFile(t *testing.T, dir, name, contents string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+}
+
+func TestLocalSource_Range(t *testing.T) {
+	dir := newTestRepository(t)
+
+	source := &LocalSource{Dir: dir, Mode: LocalModeRange, Base: "main"}
+
+	gitDiffs, err := source.GitDiffs(context.Background(), ParseOptions{IgnoreList: []string{`\.sum$`}})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "main.go", gitDiffs[0].FilePathNew)
+	require.Equal(t, StatusModified, gitDiffs[0].Status)
+	require.Len(t, gitDiffs[0].Hunks, 1)
+
+	source.Head = "main"
+	gitDiffs, err = source.GitDiffs(context.Background(), ParseOptions{})
+	require.NoError(t, err)
+	require.Empty(t, gitDiffs)
+}
+
+func TestLocalSource_StagedAndWorkingTree(t *testing.T) {
+	dir := newTestRepository(t)
+
+	writeTestFile(t, dir, "README.md", "# Staged\n")
+	runGit(t, dir, "add", "README.md")
+	writeTestFile(t, dir, "main.go", "package main\n\n// Unstaged\n")
+	writeTestFile(t, dir, "untracked.go", "package main\n")
+
+	tests := []struct {
+		name   string
+		source *LocalSource
+		want   []string
+	}{
+		{name: "Staged", source: &LocalSource{Dir: dir, Mode: LocalModeStaged}, want: []string{"README.md"}},
+		{name: "Working tree", source: &LocalSource{Dir: dir, Mode: LocalModeWorkingTree}, want: []string{"main.go"}},
+		{name: "Working tree against HEAD", source: &LocalSource{Dir: dir, Mode: LocalModeWorkingTree, Base: "HEAD"}, want: []string{"README.md", "main.go"}},
+		{name: "Staged against main", source: &LocalSource{Dir: dir, Mode: LocalModeStaged, Base: "main"}, want: []string{"README.md", "go.sum", "main.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitDiffs, err := tt.source.GitDiffs(context.Background(), ParseOptions{})
+			require.NoError(t, err)
+
+			var paths []string
+			for _, gitDiff := range gitDiffs {
+				paths = append(paths, gitDiff.FilePathNew)
+			}
+
+			require.Equal(t, tt.want, paths)
+		})
+	}
+}
+
+func TestLocalSource_KeepPathPrefixes(t *testing.T) {
+	dir := newTestRepository(t)
+	runGit(t, dir, "config", "diff.noprefix", "true")
+
+	source := &LocalSource{Dir: dir, Mode: LocalModeRange, Base: "main"}
+
+	gitDiffs, err := source.GitDiffs(context.Background(), ParseOptions{KeepPathPrefixes: true, IgnoreList: []string{`\.sum$`}})
+	require.NoError(t, err)
+	require.Len(t, gitDiffs, 1)
+	require.Equal(t, "a/main.go", gitDiffs[0].FilePathOld)
+	require.Equal(t, "b/main.go", gitDiffs[0].FilePathNew)
+}
+
+func TestLocalSource_Errors(t *testing.T) {
+	dir := newTestRepository(t)
+
+	tests := []struct {
+		name   string
+		source *LocalSource
+		want   string
+	}{
+		{name: "Missing base", source: &LocalSource{Dir: dir}, want: "a base ref is required to diff a range"},
+		{name: "Head outside range", source: &LocalSource{Dir: dir, Mode: LocalModeStaged, Head: "main"}, want: "a head ref can only be used to diff a range"},
+		{name: "Option as ref", source: &LocalSource{Dir: dir, Base: "--output=/tmp/x"}, want: `invalid ref "--output=/tmp/x"`},
+		{name: "Unknown mode", source: &LocalSource{Dir: dir, Mode: LocalDiffMode(42)}, want: "unknown local diff mode 42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.source.GitDiffs(context.Background(), ParseOptions{})
+			require.EqualError(t, err, tt.want)
+		})
+	}
+
+	_, err := (&LocalSource{Dir: dir, Base: "no-such-branch"}).Diff(context.Background())
+	require.ErrorContains(t, err, "git diff failed")
+	require.ErrorContains(t, err, "no-such-branch")
+
+	_, err = (&LocalSource{Dir: t.TempDir(), Mode: LocalModeWorkingTree, GitPath: filepath.Join(dir, "no-such-git")}).Diff(context.Background())
+	require.ErrorContains(t, err, "git diff failed")
+}
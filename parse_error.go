@@ -0,0 +1,104 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ChunkError describes a single file diff that could not be parsed and was
+// left out of the result.
+type ChunkError struct {
+	// Offset is the byte offset at which the chunk starts in the combined
+	// diff.
+	Offset int64
+
+	// Header is the first line of the chunk, usually its "diff --git"
+	// line, to help identify the file it belongs to.
+	Header string
+
+	// Err is the reason the chunk could not be parsed.
+	Err error
+}
+
+// Error returns a description of the chunk and the reason it was skipped.
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("skipped chunk at offset %d (%q): %v", e.Offset, e.Header, e.Err)
+}
+
+// Unwrap returns the underlying parse error.
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError is returned by ParseGitDiffE when one or more file diffs could
+// not be parsed. The successfully parsed file diffs are still returned
+// alongside it.
+type ParseError struct {
+	// Chunks lists every skipped chunk, in the order they appear in the
+	// combined diff.
+	Chunks []*ChunkError
+}
+
+// Error returns a summary of all skipped chunks.
+func (e *ParseError) Error() string {
+	messages := make([]string, 0, len(e.Chunks))
+	for _, chunk := range e.Chunks {
+		messages = append(messages, chunk.Error())
+	}
+
+	return fmt.Sprintf("failed to parse %d file diff(s): %s", len(e.Chunks), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the individual chunk errors, allowing errors.Is and
+// errors.As to inspect them.
+func (e *ParseError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Chunks))
+	for _, chunk := range e.Chunks {
+		errs = append(errs, chunk)
+	}
+
+	return errs
+}
+
+// newChunkError creates a ChunkError for the given chunk, using its first
+// line as the header.
+func newChunkError(chunk diffChunk, err error) *ChunkError {
+	header, _, _ := strings.Cut(chunk.text, "\n")
+
+	return &ChunkError{Offset: chunk.offset, Header: header, Err: err}
+}
+
+// compileIgnoreList compiles every pattern of an ignore list, returning an
+// error for the first invalid one. Empty patterns never match and are
+// skipped, as in matchFile.
+func compileIgnoreList(ignoreList []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+
+	for _, pattern := range ignoreList {
+		if pattern == "" {
+			continue
+		}
+
+		rx, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+
+		compiled = append(compiled, rx)
+	}
+
+	return compiled, nil
+}
+
+// matchCompiledIgnoreList reports whether the new path of a file diff
+// matches any of the compiled ignore patterns.
+func matchCompiledIgnoreList(file *GitDiff, ignoreList []*regexp.Regexp) bool {
+	for _, rx := range ignoreList {
+		if rx.MatchString(file.FilePathNew) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package github
+
+import (
+	"errors"
+	"regexp/syntax"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitDiffE(t *testing.T) {
+	diff := `diff --git a/file1.go b/file1.go
+index 123abc..456def 100644
+--- a/file1.go
++++ b/file1.go
+@@ -1,3 +1,4 @@
++import "fmt"
+diff --git a/broken.go
+index 234bcd..567efg 100644
+diff --git a/file2.go b/file2.go
+index 345cde..678fgh 100644
+--- a/file2.go
++++ b/file2.go
+@@ -x +y @@
++import "os"
+diff --git a/go.mod b/go.mod
+index 456def..789ghi 100644
+--- a/go.mod
++++ b/go.mod
+@@ -2,5 +2,6 @@
++module example.com/project`
+
+	result, err := ParseGitDiffE(diff, ParseOptions{IgnoreList: []string{`\.mod$`}})
+
+	require.Len(t, result, 1)
+	require.Equal(t, "file1.go", result[0].FilePathNew)
+
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Len(t, parseErr.Chunks, 2)
+
+	require.Equal(t, int64(strings.Index(diff, "diff --git a/broken.go")), parseErr.Chunks[0].Offset)
+	require.Equal(t, "diff --git a/broken.go", parseErr.Chunks[0].Header)
+	require.EqualError(t, parseErr.Chunks[0].Err, "invalid file paths")
+	require.Equal(t, int64(strings.Index(diff, "diff --git a/file2.go")), parseErr.Chunks[1].Offset)
+	require.EqualError(t, parseErr.Chunks[1].Err, "invalid hunk header")
+
+	var chunkErr *ChunkError
+	require.ErrorAs(t, err, &chunkErr)
+	require.Contains(t, err.Error(), "failed to parse 2 file diff(s)")
+}
+
+func TestParseGitDiffE_NoErrors(t *testing.T) {
+	diff := `diff --git a/file1.go b/file1.go
+index 123abc..456def 100644
+--- a/file1.go
++++ b/file1.go
+@@ -1,3 +1,4 @@
++import "fmt"`
+
+	result, err := ParseGitDiffE(diff, ParseOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+}
+
+func TestParseGitDiffE_InvalidIgnorePattern(t *testing.T) {
+	diff := `diff --git a/file1.go b/file1.go
+index 123abc..456def 100644
+--- a/file1.go
++++ b/file1.go
+@@ -1,3 +1,4 @@
++import "fmt"`
+
+	result, err := ParseGitDiffE(diff, ParseOptions{IgnoreList: []string{`\.mod$`, "[invalid-regex"}})
+
+	require.Nil(t, result)
+	require.ErrorContains(t, err, `invalid ignore pattern "[invalid-regex"`)
+
+	var syntaxErr *syntax.Error
+	require.True(t, errors.As(err, &syntaxErr))
+}
+
+func TestSplitDiffIntoChunks_Offsets(t *testing.T) {
+	diff := "\n\ndiff --git a/f1 b/f1\nindex 1..2\n\ndiff --git a/f2 b/f2\n"
+
+	chunks := splitDiffIntoChunks(diff)
+
+	require.Equal(t, []diffChunk{
+		{offset: 2, text: "diff --git a/f1 b/f1\nindex 1..2"},
+		{offset: 35, text: "diff --git a/f2 b/f2"},
+	}, chunks)
+}
@@ -0,0 +1,366 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// patchFromLineRegex matches the line git format-patch writes at the
+	// start of every message, which carries the SHA of the commit.
+	patchFromLineRegex = regexp.MustCompile(`^From ([0-9a-f]{7,64}) Mon Sep 17 00:00:00 2001$`)
+
+	// patchSubjectPrefixRegex matches the "[PATCH n/m]" prefix git adds to
+	// the subject of every message.
+	patchSubjectPrefixRegex = regexp.MustCompile(`^\[PATCH[^\]]*\]\s*`)
+)
+
+// PatchCommit is a single commit of a patch series, as produced by git format-patch and
+// returned by GitHub for the .patch URL of a pull request.
+type PatchCommit struct {
+	// SHA is the commit hash from the "From <sha> Mon Sep 17 00:00:00 2001"
+	// line that starts the message.
+	SHA string
+
+	// Author is the name of the commit author, decoded from the From header.
+	Author string
+
+	// Email is the email address of the commit author.
+	Email string
+
+	// Date is the author date of the commit. It is the zero time if the Date
+	// header is missing or malformed.
+	Date time.Time
+
+	// Subject is the first line of the commit message, without the
+	// "[PATCH n/m]" prefix.
+	Subject string
+
+	// Message is the rest of the commit message, without the diffstat that
+	// follows the "---" separator.
+	Message string
+
+	// Files contains the parsed and non-ignored file diffs of the commit.
+	Files []*GitDiff
+}
+
+// patchMessage is a single message of a patch series along with its byte
+// offset in the series.
+type patchMessage struct {
+	sha    string
+	offset int64
+	text   string
+}
+
+// ParsePatchSeries parses a patch series in mailbox format, such as the output of
+// git format-patch --stdout or the .patch URL of a GitHub pull request, into one
+// PatchCommit per message. The diff of every commit is parsed in the same way as
+// ParseGitDiffE, applying the same options.
+//
+// Parameters:
+//   - patch: A string containing the patch series.
+//   - opts: A ParseOptions struct containing the ignore list and other parsing settings,
+//     applied to the diff of every commit.
+//
+// Returns:
+//   - A slice of PatchCommit structs, in the order the commits appear in the series.
+//   - An error if the series contains no messages or a message has malformed headers,
+//     or a *ParseError if some file diffs could not be parsed, in which case the commits
+//     are still returned without them. The offsets in the *ParseError are relative to the
+//     start of the patch series.
+//
+// Example:
+//
+//	commits, err := ParsePatchSeries(patch, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+//	for _, commit := range commits {
+//	  // Review commit.Subject and commit.Files
+//	}
+func ParsePatchSeries(patch string, opts ParseOptions) ([]*PatchCommit, error) {
+	if _, err := compileIgnoreList(opts.IgnoreList); err != nil {
+		return nil, err
+	}
+
+	carriageReturns := removedCarriageReturns(patch)
+	patch = normalizeLineEndings(patch)
+
+	messages := splitPatchSeries(patch)
+	if len(messages) == 0 {
+		if strings.TrimSpace(patch) == "" {
+			return nil, nil
+		}
+
+		return nil, errors.New("invalid patch series: no format-patch messages found")
+	}
+
+	var (
+		commits []*PatchCommit
+		skipped []*ChunkError
+	)
+
+	for _, message := range messages {
+		commit, err := parsePatchMessage(message, opts)
+
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			skipped = append(skipped, parseErr.Chunks...)
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid patch for commit %s: %w", message.sha, err)
+		}
+
+		commits = append(commits, commit)
+	}
+
+	if len(skipped) > 0 {
+		for _, chunk := range skipped {
+			chunk.Offset = carriageReturns.originalOffset(chunk.Offset)
+		}
+
+		return commits, &ParseError{Chunks: skipped}
+	}
+
+	return commits, nil
+}
+
+// carriageReturnOffsets holds, in increasing order, the offsets in the
+// normalized text of the newlines whose carriage return was removed by
+// normalizeLineEndings.
+type carriageReturnOffsets []int64
+
+// removedCarriageReturns returns the carriage returns normalizeLineEndings
+// removes from text.
+func removedCarriageReturns(text string) carriageReturnOffsets {
+	var offsets carriageReturnOffsets
+
+	for i := 0; i+1 < len(text); i++ {
+		if text[i] == '\r' && text[i+1] == '\n' {
+			offsets = append(offsets, int64(i-len(offsets)))
+		}
+	}
+
+	return offsets
+}
+
+// originalOffset maps an offset in the normalized text back to the offset
+// of the same byte in the original text.
+func (c carriageReturnOffsets) originalOffset(offset int64) int64 {
+	removed := sort.Search(len(c), func(i int) bool { return c[i] >= offset })
+
+	return offset + int64(removed)
+}
+
+// splitPatchSeries splits a patch series into its messages at the
+// "From <sha> Mon Sep 17 00:00:00 2001" lines. Anything before the first
+// such line is ignored.
+func splitPatchSeries(patch string) []patchMessage {
+	var (
+		messages []patchMessage
+		current  *patchMessage
+		start    int
+	)
+
+	for offset := 0; offset < len(patch); {
+		end := strings.IndexByte(patch[offset:], '\n')
+		if end < 0 {
+			end = len(patch)
+		} else {
+			end += offset
+		}
+
+		if matches := patchFromLineRegex.FindStringSubmatch(patch[offset:end]); matches != nil {
+			if current != nil {
+				current.text = patch[start:offset]
+				messages = append(messages, *current)
+			}
+
+			current = &patchMessage{sha: matches[1], offset: int64(offset)}
+			start = offset
+		}
+
+		offset = end + 1
+	}
+
+	if current != nil {
+		current.text = patch[start:]
+		messages = append(messages, *current)
+	}
+
+	return messages
+}
+
+// parsePatchMessage parses the headers, commit message and diff of a
+// single message of a patch series.
+func parsePatchMessage(message patchMessage, opts ParseOptions) (*PatchCommit, error) {
+	// The first line is the "From <sha>" line, which is not a header.
+	_, text, _ := strings.Cut(message.text, "\n")
+	textOffset := message.offset + int64(len(message.text)-len(text))
+
+	msg, err := mail.ReadMessage(strings.NewReader(text))
+	if err != nil {
+		return nil, err
+	}
+
+	commit := &PatchCommit{SHA: message.sha}
+
+	decoder := &mime.WordDecoder{}
+
+	if address, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		commit.Author, commit.Email = address.Name, address.Address
+	} else if from, err := decoder.DecodeHeader(msg.Header.Get("From")); err == nil {
+		commit.Author = from
+	}
+
+	if date, err := msg.Header.Date(); err == nil {
+		commit.Date = date
+	}
+
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	commit.Subject = patchSubjectPrefixRegex.ReplaceAllString(subject, "")
+
+	// The body starts after the blank line that ends the headers.
+	bodyStart := strings.Index(text, "\n\n")
+	if bodyStart < 0 {
+		return commit, nil
+	}
+
+	bodyStart += 2
+	body := text[bodyStart:]
+
+	diffStart := 0
+	if !strings.HasPrefix(body, "diff --git ") {
+		diffStart = strings.Index(body, "\ndiff --git ")
+		if diffStart >= 0 {
+			diffStart++
+		}
+	}
+
+	if diffStart < 0 {
+		commit.Message = patchCommitMessage(body)
+
+		return commit, nil
+	}
+
+	commit.Message = patchCommitMessage(body[:diffStart])
+
+	files, err := ParseGitDiffE(stripPatchSignature(body[diffStart:]), opts)
+	commit.Files = files
+
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		for _, chunk := range parseErr.Chunks {
+			chunk.Offset += textOffset + int64(bodyStart+diffStart)
+		}
+	}
+
+	return commit, err
+}
+
+// patchCommitMessage returns the commit message part of a message body,
+// which ends at the "---" line that precedes the diffstat.
+func patchCommitMessage(body string) string {
+	if body == "---" || strings.HasPrefix(body, "---\n") {
+		return ""
+	}
+
+	if i := strings.Index(body, "\n---\n"); i >= 0 {
+		body = body[:i]
+	} else {
+		body = strings.TrimSuffix(body, "\n---")
+	}
+
+	return strings.TrimSpace(body)
+}
+
+// stripPatchSignature removes the "-- " line and the git version that git
+// format-patch appends to every message. ParseHunks already stops at the
+// end of each hunk, but the signature would otherwise remain in the
+// DiffContents of the last file, or be taken for content of a file diff
+// without hunks. A "-- " line inside a hunk that has not reached the line
+// counts declared in its header is a removed "- " line, and is kept.
+func stripPatchSignature(diff string) string {
+	i := strings.LastIndex("\n"+diff, "\n-- \n")
+	if i < 0 || lastHunkOpen(strings.TrimSuffix(diff[:i], "\n")) {
+		return diff
+	}
+
+	return diff[:i]
+}
+
+// lastHunkOpen reports whether the last hunk of a diff is still expecting
+// lines at the end of the diff.
+func lastHunkOpen(diff string) bool {
+	if j := strings.LastIndex("\n"+diff, "\ndiff --git "); j >= 0 {
+		diff = diff[j:]
+	}
+
+	hunks, err := ParseHunks(diff)
+	if err != nil || len(hunks) == 0 {
+		return false
+	}
+
+	last := hunks[len(hunks)-1]
+	oldCount, newCount := countHunkLines(last.Lines)
+
+	return oldCount < last.OldLines || newCount < last.NewLines
+}
+
+// GetPullRequestPatchSeries retrieves the patch series of a pull request and parses it
+// into one PatchCommit per commit, each with its own file diffs. It is equivalent to
+// calling Fetcher.PatchSeries on a Fetcher created with WithGitHubClient(client).
+//
+// Parameters:
+//   - ctx: A context.Context object, used for managing the lifecycle of the request.
+//   - pr: A pointer to a PullRequestURL struct, containing the owner, repository, and pull request number.
+//   - client: An implementation of the GitHubClientInterface, used to download the patch.
+//   - opts: A ParseOptions struct containing the ignore list and other parsing settings.
+//
+// Returns:
+//   - A slice of PatchCommit structs, in the order the commits were made.
+//   - An error if the patch cannot be retrieved or parsed, as described for ParsePatchSeries.
+//
+// Example:
+//
+//	commits, err := GetPullRequestPatchSeries(ctx, prURL, ghClient, ParseOptions{})
+//	if err != nil {
+//	  // Handle error
+//	}
+//	for _, commit := range commits {
+//	  // Review commit.SHA, commit.Subject and commit.Files one commit at a time
+//	}
+func GetPullRequestPatchSeries(
+	ctx context.Context,
+	pr *PullRequestURL,
+	client GitHubClientInterface,
+	opts ParseOptions,
+) ([]*PatchCommit, error) {
+	fetcher, err := NewFetcher(WithGitHubClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.PatchSeries(ctx, pr, opts)
+}
+
+// PatchSeries retrieves the patch series of a pull request with Patch and parses it with
+// ParsePatchSeries.
+func (f *Fetcher) PatchSeries(ctx context.Context, pr *PullRequestURL, opts ParseOptions) ([]*PatchCommit, error) {
+	patch, err := f.Patch(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePatchSeries(patch, opts)
+}
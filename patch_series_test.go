@@ -0,0 +1,159 @@
+package github
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+const testPatchSeries = `From cf70597cd37d7cad1cbe6a4de77e031afb251a42 Mon Sep 17 00:00:00 2001
+From: =?UTF-8?q?J=C3=B6hn=20Doe?= <j@example.com>
+Date: Fri, 16 Oct 2026 04:41:58 +0000
+Subject: [PATCH 1/2] Add b to f and a very long subject line that will surely
+ be folded by git format-patch because it is long
+
+Body line one.
+
+Body line two.
+---
+ f.txt | 1 +
+ g.md  | 1 +
+ 2 files changed, 2 insertions(+)
+ create mode 100644 g.md
+
+diff --git a/f.txt b/f.txt
+index 7898192..422c2b7 100644
+--- a/f.txt
++++ b/f.txt
+@@ -1 +1,2 @@
+ a
++b
+diff --git a/g.md b/g.md
+new file mode 100644
+index 0000000..587be6b
+--- /dev/null
++++ b/g.md
+@@ -0,0 +1 @@
++x
+-- 
+2.39.5
+
+
+From 97d784660741348ea1744036e050aa61b19a246c Mon Sep 17 00:00:00 2001
+From: =?UTF-8?q?J=C3=B6hn=20Doe?= <j@example.com>
+Date: Fri, 16 Oct 2026 04:41:59 +0000
+Subject: [PATCH 2/2] Rename g
+
+---
+ g.md => h.md | 0
+ 1 file changed, 0 insertions(+), 0 deletions(-)
+ rename g.md => h.md (100%)
+
+diff --git a/g.md b/h.md
+similarity index 100%
+rename from g.md
+rename to h.md
+-- 
+2.39.5
+`
+
+func TestParsePatchSeries(t *testing.T) {
+	commits, err := ParsePatchSeries(testPatchSeries, ParseOptions{IgnoreList: []string{`\.md$`}})
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+
+	first := commits[0]
+	require.Equal(t, "cf70597cd37d7cad1cbe6a4de77e031afb251a42", first.SHA)
+	require.Equal(t, "Jöhn Doe", first.Author)
+	require.Equal(t, "j@example.com", first.Email)
+	require.True(t, time.Date(2026, 10, 16, 4, 41, 58, 0, time.UTC).Equal(first.Date))
+	require.Equal(t,
+		"Add b to f and a very long subject line that will surely be folded by git format-patch because it is long",
+		first.Subject,
+	)
+	require.Equal(t, "Body line one.\n\nBody line two.", first.Message)
+	require.Len(t, first.Files, 1)
+	require.Equal(t, "f.txt", first.Files[0].FilePathNew)
+	require.Equal(t, "--- a/f.txt\n+++ b/f.txt\n@@ -1 +1,2 @@\n a\n+b", first.Files[0].DiffContents)
+
+	second := commits[1]
+	require.Equal(t, "97d784660741348ea1744036e050aa61b19a246c", second.SHA)
+	require.Equal(t, "Rename g", second.Subject)
+	require.Empty(t, second.Message)
+	require.Empty(t, second.Files)
+
+	commits, err = ParsePatchSeries(testPatchSeries, ParseOptions{})
+	require.NoError(t, err)
+	require.Len(t, commits[0].Files, 2)
+	require.Equal(t, "+x", commits[0].Files[1].DiffContents[len(commits[0].Files[1].DiffContents)-2:])
+	require.Len(t, commits[1].Files, 1)
+	require.Equal(t, StatusRenamed, commits[1].Files[0].Status)
+	require.Equal(t, "h.md", commits[1].Files[0].FilePathNew)
+}
+
+func TestParsePatchSeries_ParseErrorOffsets(t *testing.T) {
+	patch := strings.Replace(testPatchSeries, "diff --git a/g.md b/h.md", "diff --git a/g.md", 1)
+
+	commits, err := ParsePatchSeries(patch, ParseOptions{})
+	require.Len(t, commits, 2)
+
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	require.Len(t, parseErr.Chunks, 1)
+	require.Equal(t, int64(strings.Index(patch, "diff --git a/g.md\n")), parseErr.Chunks[0].Offset)
+
+	patch = strings.ReplaceAll(patch, "\n", "\r\n")
+
+	commits, err = ParsePatchSeries(patch, ParseOptions{})
+	require.Len(t, commits, 2)
+	require.ErrorAs(t, err, &parseErr)
+	require.Len(t, parseErr.Chunks, 1)
+	require.Equal(t, int64(strings.Index(patch, "diff --git a/g.md\r\n")), parseErr.Chunks[0].Offset)
+}
+
+func TestParsePatchSeries_Invalid(t *testing.T) {
+	commits, err := ParsePatchSeries("", ParseOptions{})
+	require.NoError(t, err)
+	require.Empty(t, commits)
+
+	_, err = ParsePatchSeries("diff --git a/f b/f\n", ParseOptions{})
+	require.Error(t, err)
+
+	_, err = ParsePatchSeries(testPatchSeries, ParseOptions{IgnoreList: []string{"[invalid"}})
+	require.ErrorContains(t, err, "invalid ignore pattern")
+}
+
+func TestStripPatchSignature(t *testing.T) {
+	require.Equal(t, "+a\n", stripPatchSignature("+a\n-- \n2.39.5\n\n"))
+	require.Equal(t, "", stripPatchSignature("-- \n2.39.5\n"))
+	require.Equal(t, "@@ -1 +1 @@\n-a\n+b\n", stripPatchSignature("@@ -1 +1 @@\n-a\n+b\n-- \n2.39.5\n"))
+	require.Equal(t, "@@ -1,4 +1,2 @@\n-a\n-- \n b\n c\n", stripPatchSignature("@@ -1,4 +1,2 @@\n-a\n-- \n b\n c\n"))
+	require.Equal(t, "@@ -1,2 +0,0 @@\n-a\n-- \n", stripPatchSignature("@@ -1,2 +0,0 @@\n-a\n-- \n"))
+}
+
+func TestGetPullRequestPatchSeries(t *testing.T) {
+	mockClient := &MockGitClient{
+		MockGetRaw: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			require.Equal(t, github.Patch, opts.Type)
+
+			return io.NopCloser(strings.NewReader(testPatchSeries)), nil, nil
+		},
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 1}
+	commits, err := GetPullRequestPatchSeries(context.Background(), prURL, mockClient, ParseOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	require.Equal(t, "Rename g", commits[1].Subject)
+}
@@ -0,0 +1,259 @@
+package github
+
+import (
+	"errors"
+	"strings"
+)
+
+// pathHints holds the file names found in the extended header and the
+// "---"/"+++" lines of a file diff. They are used to split the paths on
+// the "diff --git" line when the names contain spaces.
+type pathHints struct {
+	// oldName and newName come from "rename from/to" or "copy from/to"
+	// headers and never carry a prefix.
+	oldName string
+	newName string
+
+	// oldFile and newFile come from the "---" and "+++" lines and keep
+	// their prefix. They are empty for /dev/null or when absent.
+	oldFile string
+	newFile string
+}
+
+// parseDiffGitPaths splits the remainder of a "diff --git" line into the
+// old and new paths, including their prefixes. Paths that git quoted are
+// unquoted. Unquoted paths may contain spaces, in which case the hints
+// are used to find where the old path ends and the new path begins.
+func parseDiffGitPaths(rest string, hints pathHints) (string, string, error) {
+	if strings.HasPrefix(rest, `"`) {
+		oldPath, n, err := unquoteGitPath(rest)
+		if err != nil {
+			return "", "", err
+		}
+
+		if !strings.HasPrefix(rest[n:], " ") {
+			return "", "", errors.New("invalid file paths")
+		}
+
+		newPath, err := parsePathToken(rest[n+1:])
+		if err != nil {
+			return "", "", err
+		}
+
+		return oldPath, newPath, nil
+	}
+
+	// An unquoted path cannot contain a double quote, so the first one
+	// starts a quoted new path.
+	if i := strings.Index(rest, ` "`); i >= 0 {
+		newPath, err := parsePathToken(rest[i+1:])
+		if err != nil {
+			return "", "", err
+		}
+
+		return rest[:i], newPath, nil
+	}
+
+	var splits []int
+	for i := 0; i < len(rest); i++ {
+		if rest[i] == ' ' {
+			splits = append(splits, i)
+		}
+	}
+
+	if len(splits) == 0 {
+		return "", "", errors.New("invalid file paths")
+	}
+
+	if len(splits) == 1 {
+		return rest[:splits[0]], rest[splits[0]+1:], nil
+	}
+
+	matchers := []func(oldPath, newPath string) bool{
+		func(oldPath, newPath string) bool {
+			return hints.oldFile != "" && hints.newFile != "" &&
+				oldPath == hints.oldFile && newPath == hints.newFile
+		},
+		func(oldPath, newPath string) bool {
+			return (hints.oldFile != "" && oldPath == hints.oldFile) ||
+				(hints.newFile != "" && newPath == hints.newFile)
+		},
+		func(oldPath, newPath string) bool {
+			return hints.oldName != "" && hints.newName != "" &&
+				stripFirstComponent(oldPath) == hints.oldName &&
+				stripFirstComponent(newPath) == hints.newName
+		},
+		func(oldPath, newPath string) bool {
+			return stripFirstComponent(oldPath) == stripFirstComponent(newPath)
+		},
+	}
+
+	for _, match := range matchers {
+		for _, i := range splits {
+			if match(rest[:i], rest[i+1:]) {
+				return rest[:i], rest[i+1:], nil
+			}
+		}
+	}
+
+	return "", "", errors.New("invalid file paths")
+}
+
+// parseFileLinePath extracts the path from a "---" or "+++" line value,
+// returning an empty string for /dev/null. Git appends a tab to unquoted
+// names containing spaces, and GNU diff appends a tab and a timestamp, so
+// anything after the first tab is dropped.
+func parseFileLinePath(value string) string {
+	if strings.HasPrefix(value, `"`) {
+		path, _, err := unquoteGitPath(value)
+		if err != nil {
+			return ""
+		}
+
+		return path
+	}
+
+	if i := strings.IndexByte(value, '\t'); i >= 0 {
+		value = value[:i]
+	}
+
+	if value == "/dev/null" {
+		return ""
+	}
+
+	return value
+}
+
+// parsePathToken returns the path in s, unquoting it if it starts with a
+// double quote. The path must span the whole of s.
+func parsePathToken(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return s, nil
+	}
+
+	path, n, err := unquoteGitPath(s)
+	if err != nil {
+		return "", err
+	}
+
+	if n != len(s) {
+		return "", errors.New("invalid file paths")
+	}
+
+	return path, nil
+}
+
+// unquoteGitPath decodes a C-style quoted path at the start of s, as
+// written by git for names containing special or non-ASCII characters.
+// It supports the escapes \a, \b, \t, \n, \v, \f, \r, \", \\ and
+// three-digit octal byte values. It returns the decoded path and the
+// number of bytes of s consumed, including both quotes.
+func unquoteGitPath(s string) (string, int, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", 0, errors.New("invalid quoted path")
+	}
+
+	var path strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+
+		switch c {
+		case '"':
+			return path.String(), i + 1, nil
+		case '\\':
+			i++
+			if i >= len(s) {
+				return "", 0, errors.New("invalid quoted path")
+			}
+
+			switch e := s[i]; e {
+			case 'a':
+				path.WriteByte('\a')
+			case 'b':
+				path.WriteByte('\b')
+			case 't':
+				path.WriteByte('\t')
+			case 'n':
+				path.WriteByte('\n')
+			case 'v':
+				path.WriteByte('\v')
+			case 'f':
+				path.WriteByte('\f')
+			case 'r':
+				path.WriteByte('\r')
+			case '"', '\\':
+				path.WriteByte(e)
+			default:
+				if i+3 > len(s) || !isOctal(s[i]) || !isOctal(s[i+1]) || !isOctal(s[i+2]) {
+					return "", 0, errors.New("invalid escape in quoted path")
+				}
+
+				value := (int(s[i]-'0') << 6) | (int(s[i+1]-'0') << 3) | int(s[i+2]-'0')
+				if value > 0xff {
+					return "", 0, errors.New("invalid escape in quoted path")
+				}
+
+				path.WriteByte(byte(value))
+				i += 2
+			}
+		default:
+			path.WriteByte(c)
+		}
+	}
+
+	return "", 0, errors.New("unterminated quoted path")
+}
+
+// isOctal reports whether c is an octal digit.
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// stripFirstComponent removes everything up to and including the first
+// slash of a path, which is where git puts the "a/" and "b/" prefixes.
+func stripFirstComponent(path string) string {
+	if i := strings.IndexByte(path, '/'); i >= 0 {
+		return path[i+1:]
+	}
+
+	return path
+}
+
+// parseHeaderPathHints records the file names given by "rename from/to"
+// and "copy from/to" extended headers.
+func parseHeaderPathHints(line string, hints *pathHints) {
+	var (
+		value  string
+		target *string
+	)
+
+	switch {
+	case strings.HasPrefix(line, "rename from "):
+		value, target = strings.TrimPrefix(line, "rename from "), &hints.oldName
+	case strings.HasPrefix(line, "rename to "):
+		value, target = strings.TrimPrefix(line, "rename to "), &hints.newName
+	case strings.HasPrefix(line, "copy from "):
+		value, target = strings.TrimPrefix(line, "copy from "), &hints.oldName
+	case strings.HasPrefix(line, "copy to "):
+		value, target = strings.TrimPrefix(line, "copy to "), &hints.newName
+	default:
+		return
+	}
+
+	if name, err := parsePathToken(value); err == nil {
+		*target = name
+	}
+}
+
+// stripDiffPrefixes removes the "a/" and "b/" prefixes git adds to the old
+// and new paths. For renames and copies the unprefixed names from the
+// extended headers are used instead, as they do not depend on the prefix
+// configuration of the diff.
+func stripDiffPrefixes(oldPath, newPath string, hints pathHints) (string, string) {
+	if hints.oldName != "" && hints.newName != "" {
+		return hints.oldName, hints.newName
+	}
+
+	return strings.TrimPrefix(oldPath, "a/"), strings.TrimPrefix(newPath, "b/")
+}
@@ -0,0 +1,139 @@
+package github
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGitDiff_Paths(t *testing.T) {
+	diff := "diff --git \"a/caf\\303\\251.txt\" \"b/caf\\303\\251.txt\"\n" +
+		"index 6178079..c3219eb 100644\n" +
+		"--- \"a/caf\\303\\251.txt\"\n" +
+		"+++ \"b/caf\\303\\251.txt\"\n" +
+		"@@ -1 +1,2 @@\n" +
+		" b\n" +
+		"+d\n" +
+		"diff --git \"a/q\\\"uote.txt\" \"b/q\\\"uote.txt\"\n" +
+		"index 587be6b..937eef3 100644\n" +
+		"--- \"a/q\\\"uote.txt\"\n" +
+		"+++ \"b/q\\\"uote.txt\"\n" +
+		"@@ -1 +1,2 @@\n" +
+		" x\n" +
+		"+e\n" +
+		"diff --git a/my file.txt b/your file.txt\n" +
+		"similarity index 50%\n" +
+		"rename from my file.txt\n" +
+		"rename to your file.txt\n" +
+		"index 7898192..0f7bc76 100644\n" +
+		"--- a/my file.txt\t\n" +
+		"+++ b/your file.txt\t\n" +
+		"@@ -1 +1,2 @@\n" +
+		" a\n" +
+		"+c\n" +
+		"diff --git a/dir a/file b/dir a/file\n" +
+		"old mode 100644\n" +
+		"new mode 100755\n" +
+		"diff --git a/new file.txt b/new file.txt\n" +
+		"new file mode 100644\n" +
+		"index 0000000..7898192\n" +
+		"--- /dev/null\n" +
+		"+++ b/new file.txt\t\n" +
+		"@@ -0,0 +1 @@\n" +
+		"+a"
+
+	tests := []struct {
+		name    string
+		opts    ParseOptions
+		oldPath []string
+		newPath []string
+	}{
+		{
+			name:    "Prefixes stripped",
+			opts:    ParseOptions{},
+			oldPath: []string{"café.txt", `q"uote.txt`, "my file.txt", "dir a/file", "new file.txt"},
+			newPath: []string{"café.txt", `q"uote.txt`, "your file.txt", "dir a/file", "new file.txt"},
+		},
+		{
+			name:    "Prefixes kept",
+			opts:    ParseOptions{KeepPathPrefixes: true},
+			oldPath: []string{"a/café.txt", `a/q"uote.txt`, "a/my file.txt", "a/dir a/file", "a/new file.txt"},
+			newPath: []string{"b/café.txt", `b/q"uote.txt`, "b/your file.txt", "b/dir a/file", "b/new file.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ParseGitDiffWithOptions(diff, tt.opts)
+
+			require.Len(t, result, len(tt.oldPath))
+
+			for i, gitDiff := range result {
+				require.Equal(t, tt.oldPath[i], gitDiff.FilePathOld)
+				require.Equal(t, tt.newPath[i], gitDiff.FilePathNew)
+			}
+		})
+	}
+}
+
+func TestParseGitDiffWithOptions_IgnoreList(t *testing.T) {
+	diff := `diff --git a/src/main.go b/src/main.go
+index 1111111..2222222 100644
+--- a/src/main.go
++++ b/src/main.go
+@@ -1 +1 @@
+-package a
++package main
+diff --git a/vendor/lib.go b/vendor/lib.go
+index 3333333..4444444 100644
+--- a/vendor/lib.go
++++ b/vendor/lib.go
+@@ -1 +1 @@
+-package a
++package lib`
+
+	result := ParseGitDiffWithOptions(diff, ParseOptions{IgnoreList: []string{`^vendor/`}})
+
+	require.Len(t, result, 1)
+	require.Equal(t, "src/main.go", result[0].FilePathNew)
+}
+
+func TestUnquoteGitPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     string
+		consumed int
+		wantErr  bool
+	}{
+		{name: "Plain", input: `"a/file.txt"`, want: "a/file.txt", consumed: 12},
+		{name: "Octal escapes", input: `"caf\303\251" rest`, want: "café", consumed: 13},
+		{name: "Quote and backslash", input: `"a\"b\\c"`, want: `a"b\c`, consumed: 9},
+		{name: "Control characters", input: `"a\tb\nc"`, want: "a\tb\nc", consumed: 9},
+		{name: "Unterminated", input: `"abc`, wantErr: true},
+		{name: "Invalid escape", input: `"a\qb"`, wantErr: true},
+		{name: "Not quoted", input: `abc`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, consumed, err := unquoteGitPath(tt.input)
+
+			if tt.wantErr {
+				require.Error(t, err)
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+			require.Equal(t, tt.consumed, consumed)
+		})
+	}
+}
+
+func TestParseDiffGitPaths_Ambiguous(t *testing.T) {
+	_, _, err := parseDiffGitPaths("a/x y b/z w", pathHints{})
+
+	require.Error(t, err)
+}
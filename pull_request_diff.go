@@ -0,0 +1,127 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// PullRequestDiff combines the details of a pull request with the parsed
+// diff of every file it changes.
+type PullRequestDiff struct {
+	// PullRequest contains the details of the pull request, as returned by
+	// GetPullRequestWithDetails.
+	PullRequest *github.PullRequest
+
+	// Files contains the parsed and non-ignored file diffs of the pull
+	// request. When the client can list the files of the pull request, the
+	// CommitFile field of each GitDiff holds the matching entry of the list.
+	Files []*GitDiff
+}
+
+// GetPullRequestDiff retrieves the details, the file list and the Git diff of a pull request,
+// and returns them as a single PullRequestDiff. Each parsed GitDiff is linked to the entry of
+// the file list with the same path through its CommitFile field, which gives access to the
+// per-file data of the GitHub API, such as the addition, deletion and change counts, the blob
+// URL, the SHA and the previous file name.
+//
+// Parameters:
+//   - ctx: A context.Context object, used for managing the lifecycle of the requests.
+//   - pr: A pointer to a PullRequestURL struct, containing the owner, repository, and pull request number.
+//   - client: An implementation of the GitHubClientInterface. The file list is only retrieved if
+//     the client also implements GitHubFilesClientInterface, as GitHubClientWrapper does;
+//     otherwise the CommitFile field of every GitDiff is nil.
+//   - opts: A ParseOptions struct containing the ignore list and other parsing settings.
+//
+// Returns:
+//   - A pointer to a PullRequestDiff struct containing the pull request details and its file diffs.
+//   - An error if any of the requests fail, or a *ParseError if some file diffs could not be
+//     parsed, in which case the PullRequestDiff is returned with the remaining file diffs.
+//
+// As with GetPullRequestGitDiffs, the file diffs are rebuilt from the file list if the diff is
+// too large for GitHub to render, without listing the files a second time.
+//
+// Example:
+//
+//	prDiff, err := GetPullRequestDiff(ctx, prURL, ghClient, ParseOptions{IgnoreList: ignoreList})
+//	if err != nil {
+//	  // Handle error
+//	}
+//	for _, gitDiff := range prDiff.Files {
+//	  if gitDiff.CommitFile != nil {
+//	    // Use gitDiff.CommitFile.GetAdditions() and gitDiff.CommitFile.GetDeletions()
+//	  }
+//	}
+func GetPullRequestDiff(
+	ctx context.Context,
+	pr *PullRequestURL,
+	client GitHubClientInterface,
+	opts ParseOptions,
+) (*PullRequestDiff, error) {
+	fetcher, err := NewFetcher(WithGitHubClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	return fetcher.PullRequestDiff(ctx, pr, opts)
+}
+
+// PullRequestDiff retrieves the details, the file list and the Git diff of a pull request
+// with the Fetcher's GitHub client, as described for GetPullRequestDiff, so that every
+// request, including the listing of the files, uses the Fetcher's retry policy.
+func (f *Fetcher) PullRequestDiff(ctx context.Context, pr *PullRequestURL, opts ParseOptions) (*PullRequestDiff, error) {
+	pullRequest, err := f.PullRequest(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := f.gitHubClient(pr.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*github.CommitFile
+
+	if filesClient, ok := client.(GitHubFilesClientInterface); ok {
+		files, err = listPullRequestFiles(ctx, pr, filesClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	gitDiffs, err := f.pullRequestGitDiffs(ctx, pr, opts, files)
+
+	var parseErr *ParseError
+	if err != nil && !errors.As(err, &parseErr) {
+		return nil, err
+	}
+
+	linkCommitFiles(gitDiffs, files, opts.KeepPathPrefixes)
+
+	return &PullRequestDiff{PullRequest: pullRequest, Files: gitDiffs}, err
+}
+
+// linkCommitFiles sets the CommitFile field of every file diff that does
+// not have one yet to the entry of the file list with the same path. If
+// keepPathPrefixes is set, the "b/" prefix of the paths is ignored.
+func linkCommitFiles(gitDiffs []*GitDiff, files []*github.CommitFile, keepPathPrefixes bool) {
+	byName := make(map[string]*github.CommitFile, len(files))
+	for _, file := range files {
+		byName[file.GetFilename()] = file
+	}
+
+	for _, gitDiff := range gitDiffs {
+		if gitDiff.CommitFile != nil {
+			continue
+		}
+
+		path := gitDiff.FilePathNew
+		if keepPathPrefixes {
+			path = strings.TrimPrefix(path, "b/")
+		}
+
+		gitDiff.CommitFile = byName[path]
+	}
+}
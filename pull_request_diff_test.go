@@ -0,0 +1,191 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPullRequestDiff(t *testing.T) {
+	diff := `diff --git a/file1.go b/file1.go
+index 123abc..456def 100644
+--- a/file1.go
++++ b/file1.go
+@@ -1 +1,2 @@
+ package a
++import "fmt"
+diff --git a/docs/README.md b/docs/README.md
+index 234bcd..567efa 100644
+--- a/docs/README.md
++++ b/docs/README.md
+@@ -1 +1 @@
+-old
++new
+`
+
+	files := []*github.CommitFile{
+		{
+			Filename:  github.String("file1.go"),
+			Status:    github.String("modified"),
+			Additions: github.Int(1),
+			Changes:   github.Int(1),
+			BlobURL:   github.String("https://github.com/user/repo/blob/abc/file1.go"),
+		},
+		{
+			Filename:  github.String("docs/README.md"),
+			Status:    github.String("modified"),
+			Additions: github.Int(1),
+			Deletions: github.Int(1),
+			Changes:   github.Int(2),
+		},
+	}
+
+	var listCalls int
+
+	mockClient := &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{Number: github.Int(number), Title: github.String("Add fmt")}, nil, nil
+		},
+		MockGetRaw: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			return io.NopCloser(strings.NewReader(diff)), nil, nil
+		},
+		MockListFiles: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts *github.ListOptions,
+		) ([]*github.CommitFile, *github.Response, error) {
+			listCalls++
+
+			return files, nil, nil
+		},
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 5}
+
+	prDiff, err := GetPullRequestDiff(context.Background(), prURL, mockClient, ParseOptions{IgnoreList: []string{`\.md$`}})
+	require.NoError(t, err)
+	require.Equal(t, 1, listCalls)
+	require.Equal(t, "Add fmt", prDiff.PullRequest.GetTitle())
+	require.Len(t, prDiff.Files, 1)
+	require.Equal(t, "file1.go", prDiff.Files[0].FilePathNew)
+	require.Same(t, files[0], prDiff.Files[0].CommitFile)
+	require.Equal(t, 1, prDiff.Files[0].CommitFile.GetAdditions())
+
+	prDiff, err = GetPullRequestDiff(context.Background(), prURL, mockClient, ParseOptions{KeepPathPrefixes: true})
+	require.NoError(t, err)
+	require.Len(t, prDiff.Files, 2)
+	require.Same(t, files[1], prDiff.Files[1].CommitFile)
+}
+
+func TestGetPullRequestDiff_DiffTooLargeListsFilesOnce(t *testing.T) {
+	var listCalls int
+
+	mockClient := &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{Number: github.Int(number)}, nil, nil
+		},
+		MockGetRaw: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts github.RawOptions,
+		) (io.ReadCloser, *github.Response, error) {
+			return nil, nil, &HTTPStatusError{StatusCode: 406}
+		},
+		MockListFiles: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts *github.ListOptions,
+		) ([]*github.CommitFile, *github.Response, error) {
+			listCalls++
+
+			return []*github.CommitFile{{
+				Filename:  github.String("main.go"),
+				Status:    github.String("added"),
+				Additions: github.Int(1),
+				Changes:   github.Int(1),
+				Patch:     github.String("@@ -0,0 +1 @@\n+package main"),
+			}}, nil, nil
+		},
+	}
+
+	prURL := &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 5}
+
+	prDiff, err := GetPullRequestDiff(context.Background(), prURL, mockClient, ParseOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, listCalls)
+	require.Len(t, prDiff.Files, 1)
+	require.Equal(t, "main.go", prDiff.Files[0].CommitFile.GetFilename())
+}
+
+func TestGetPullRequestDiff_ListFilesError(t *testing.T) {
+	listErr := errors.New("API error")
+
+	mockClient := &MockGitClient{
+		MockGet: func(ctx context.Context, owner, repo string, number int) (*github.PullRequest, *github.Response, error) {
+			return &github.PullRequest{}, nil, nil
+		},
+		MockListFiles: func(
+			ctx context.Context,
+			owner, repo string,
+			number int,
+			opts *github.ListOptions,
+		) ([]*github.CommitFile, *github.Response, error) {
+			return nil, nil, listErr
+		},
+	}
+
+	prDiff, err := GetPullRequestDiff(context.Background(), &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 5}, mockClient, ParseOptions{})
+
+	require.ErrorIs(t, err, listErr)
+	require.Nil(t, prDiff)
+}
+
+func TestFetcher_PullRequestDiffRetriesListFiles(t *testing.T) {
+	var listAttempts int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/user/repo/pulls/5/files":
+			listAttempts++
+			if listAttempts == 1 {
+				http.Error(w, `{"message": "Server Error"}`, http.StatusBadGateway)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`[{"filename": "main.go", "status": "modified", "additions": 1, "changes": 1}]`))
+		case r.Header.Get("Accept") == "application/vnd.github.v3.diff":
+			_, _ = w.Write([]byte("diff --git a/main.go b/main.go\nindex 1..2 100644\n--- a/main.go\n+++ b/main.go\n@@ -1 +1,2 @@\n a\n+b\n"))
+		default:
+			_, _ = w.Write([]byte(`{"number": 5}`))
+		}
+	}))
+	defer testServer.Close()
+
+	fetcher, err := NewFetcher(
+		WithGitHubClient(newTestGitHubClient(t, testServer, "")),
+		WithRetry(RetryPolicy{MaxAttempts: 2}),
+	)
+	require.NoError(t, err)
+
+	prDiff, err := fetcher.PullRequestDiff(context.Background(), &PullRequestURL{Owner: "user", Repo: "repo", PRNumber: 5}, ParseOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, listAttempts)
+	require.Len(t, prDiff.Files, 1)
+	require.Equal(t, 1, prDiff.Files[0].CommitFile.GetAdditions())
+}
@@ -0,0 +1,308 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// maxDrainBytes is the maximum number of bytes read from the body of a
+// response that is discarded before a retry, so that the connection can be
+// reused.
+const maxDrainBytes = 4096
+
+// secondaryRateLimitWait is the shortest delay before retrying a request
+// rejected by GitHub's secondary rate limits without a Retry-After header,
+// as recommended by GitHub.
+const secondaryRateLimitWait = time.Minute
+
+// diffHTTPClient is the HTTP client used to download diffs from the diff URL
+// of a pull request.
+var diffHTTPClient = newRetryHTTPClient()
+
+// RetryPolicy configures how RetryTransport retries failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent,
+	// including the first attempt. Values of 1 or less disable retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry. The delay is
+	// doubled after every attempt, and a random jitter of up to half the
+	// delay is subtracted from it.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay computed from InitialBackoff.
+	MaxBackoff time.Duration
+
+	// MaxWait is the longest delay requested by a Retry-After or
+	// X-RateLimit-Reset header that is honored. If the server asks the
+	// client to wait longer, the response is returned without retrying.
+	// A value of 0 means no limit.
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by the package when no
+// other policy is configured: up to four attempts, with delays starting at
+// one second and capped at thirty seconds, honoring rate limit waits of up
+// to five minutes.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		MaxWait:        5 * time.Minute,
+	}
+}
+
+// RetryTransport is an http.RoundTripper that retries idempotent requests
+// according to a RetryPolicy. GET and HEAD requests are retried when they
+// fail with a network error, a 5xx or 429 status code, or a 403 status
+// code caused by GitHub's primary or secondary rate limits. Retry-After
+// and X-RateLimit-Reset headers take precedence over the exponential
+// backoff of the policy. A 403 response whose body reports a secondary
+// rate limit without either header is retried after at least a minute.
+// Waiting between attempts is aborted when the context of the request is
+// canceled.
+//
+// RetryTransports may wrap each other, either directly or through other
+// transports such as the authenticating transport added by
+// github.Client.WithAuthToken. Only the innermost one retries a request,
+// and the outer ones send it once, so that their attempts do not multiply.
+//
+// Example:
+//
+//	httpClient := &http.Client{Transport: &RetryTransport{Policy: DefaultRetryPolicy()}}
+//	ghClient, err := NewGitHubClientForHost("github.com", httpClient)
+type RetryTransport struct {
+	// Base is the transport used to send the requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// Policy configures the number of attempts and the delays between them.
+	Policy RetryPolicy
+
+	// overrideNested makes the transport retry requests itself, with inner
+	// RetryTransports sending them once, rather than leaving the retries to
+	// the innermost one.
+	overrideNested bool
+
+	// now and sleep replace time.Now and the wait between attempts in tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// nestedRetryKey is the context key of the nestedRetry of a request sent by
+// a RetryTransport.
+type nestedRetryKey struct{}
+
+// nestedRetry is shared by a RetryTransport with the RetryTransports it
+// wraps, through the context of the requests it sends, so that a request is
+// only retried by one of them.
+type nestedRetry struct {
+	// override is set when the outer RetryTransport retries the request
+	// itself, in which case inner ones send it once.
+	override bool
+
+	// retried is set by an inner RetryTransport that retries the request,
+	// in which case the outer one returns its response as is.
+	retried atomic.Bool
+}
+
+// NewRetryTransport returns a RetryTransport that sends requests with base
+// and retries them according to policy.
+func NewRetryTransport(base http.RoundTripper, policy RetryPolicy) *RetryTransport {
+	return &RetryTransport{Base: base, Policy: policy}
+}
+
+// RoundTrip sends the request, retrying it as described by RetryTransport.
+// Responses that are not retried are returned unchanged, so the caller
+// sees the last response or error if every attempt fails.
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return base.RoundTrip(req)
+	}
+
+	if outer, ok := req.Context().Value(nestedRetryKey{}).(*nestedRetry); ok {
+		if outer.override {
+			return base.RoundTrip(req)
+		}
+
+		outer.retried.Store(true)
+	}
+
+	nested := &nestedRetry{override: t.overrideNested}
+	req = req.WithContext(context.WithValue(req.Context(), nestedRetryKey{}, nested))
+
+	for attempt := 1; ; attempt++ {
+		resp, err := base.RoundTrip(req)
+
+		if attempt >= t.Policy.MaxAttempts || req.Context().Err() != nil || nested.retried.Load() {
+			return resp, err
+		}
+
+		delay, retry := t.retryDelay(resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+			closeBody(resp.Body)
+		}
+
+		if err := t.wait(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryDelay reports whether a request should be retried after the given
+// attempt, and how long to wait before doing so.
+func (t *RetryTransport) retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := t.backoff(attempt)
+
+	if err != nil {
+		return backoff, true
+	}
+
+	secondaryRateLimited := false
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError,
+		resp.StatusCode == http.StatusTooManyRequests,
+		isRateLimitedResponse(resp):
+	case resp.StatusCode == http.StatusForbidden && isSecondaryRateLimitMessage(string(peekBody(resp, maxDrainBytes))):
+		secondaryRateLimited = true
+	default:
+		return 0, false
+	}
+
+	wait, ok := t.rateLimitWait(resp)
+	if !ok {
+		if !secondaryRateLimited {
+			return backoff, true
+		}
+
+		wait = backoff
+		if wait < secondaryRateLimitWait {
+			wait = secondaryRateLimitWait
+		}
+	}
+
+	if t.Policy.MaxWait > 0 && wait > t.Policy.MaxWait {
+		return 0, false
+	}
+
+	return wait, true
+}
+
+// rateLimitWait returns the delay requested by the Retry-After header of a
+// response, or by its X-RateLimit-Reset header when its rate limit is
+// exhausted.
+func (t *RetryTransport) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			return nonNegative(date.Sub(t.currentTime())), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return nonNegative(time.Unix(reset, 0).Sub(t.currentTime())), true
+		}
+	}
+
+	return 0, false
+}
+
+// peekBody reads up to n bytes from the body of a response and puts them
+// back in front of the rest of the body, so that it can still be read in
+// full by the caller.
+func peekBody(resp *http.Response, n int64) []byte {
+	peeked, _ := io.ReadAll(io.LimitReader(resp.Body, n))
+
+	resp.Body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(peeked), resp.Body), Closer: resp.Body}
+
+	return peeked
+}
+
+// peekedBody is a response body whose beginning was read by peekBody.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// backoff returns the exponential backoff delay after the given attempt,
+// with jitter applied.
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	delay := t.Policy.InitialBackoff
+	for i := 1; i < attempt && (t.Policy.MaxBackoff <= 0 || delay < t.Policy.MaxBackoff); i++ {
+		delay *= 2
+	}
+
+	if t.Policy.MaxBackoff > 0 && delay > t.Policy.MaxBackoff {
+		delay = t.Policy.MaxBackoff
+	}
+
+	if half := int64(delay / 2); half > 0 {
+		delay -= time.Duration(rand.Int63n(half))
+	}
+
+	return delay
+}
+
+// wait blocks for the given delay or until ctx is canceled.
+func (t *RetryTransport) wait(ctx context.Context, d time.Duration) error {
+	if t.sleep != nil {
+		return t.sleep(ctx, d)
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// currentTime returns the current time, as reported by the now hook in
+// tests.
+func (t *RetryTransport) currentTime() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+
+	return time.Now()
+}
+
+// nonNegative returns d, or 0 if d is negative.
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+// newRetryHTTPClient returns an HTTP client that retries requests with the
+// default retry policy.
+func newRetryHTTPClient() *http.Client {
+	return &http.Client{Transport: NewRetryTransport(nil, DefaultRetryPolicy())}
+}
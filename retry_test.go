@@ -0,0 +1,315 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRetryTransport returns a RetryTransport that records the delays
+// between attempts instead of sleeping.
+func newTestRetryTransport(policy RetryPolicy, delays *[]time.Duration) *RetryTransport {
+	transport := NewRetryTransport(nil, policy)
+	transport.now = func() time.Time { return time.Unix(1700000000, 0) }
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		*delays = append(*delays, d)
+
+		return nil
+	}
+
+	return transport
+}
+
+func TestRetryTransport_RetriesServerErrors(t *testing.T) {
+	var attempts int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "unavailable", http.StatusBadGateway)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("mock diff"))
+	}))
+	defer testServer.Close()
+
+	var delays []time.Duration
+
+	policy := RetryPolicy{MaxAttempts: 4, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	client := &http.Client{Transport: newTestRetryTransport(policy, &delays)}
+
+	resp, err := client.Get(testServer.URL)
+	require.NoError(t, err)
+	defer closeBody(resp.Body)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 3, attempts)
+	require.Len(t, delays, 2)
+	require.InDelta(t, 75*time.Millisecond, delays[0], float64(25*time.Millisecond))
+	require.InDelta(t, 150*time.Millisecond, delays[1], float64(50*time.Millisecond))
+}
+
+func TestRetryTransport_GivesUpAfterMaxAttempts(t *testing.T) {
+	var attempts int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer testServer.Close()
+
+	var delays []time.Duration
+
+	client := &http.Client{Transport: newTestRetryTransport(RetryPolicy{MaxAttempts: 3}, &delays)}
+
+	resp, err := client.Get(testServer.URL)
+	require.NoError(t, err)
+	defer closeBody(resp.Body)
+
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, 3, attempts)
+}
+
+func TestRetryTransport_RateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		headers map[string]string
+		want    time.Duration
+	}{
+		{
+			name:    "Secondary rate limit",
+			status:  http.StatusForbidden,
+			headers: map[string]string{"Retry-After": "7"},
+			want:    7 * time.Second,
+		},
+		{
+			name:    "Too many requests with date",
+			status:  http.StatusTooManyRequests,
+			headers: map[string]string{"Retry-After": time.Unix(1700000030, 0).UTC().Format(http.TimeFormat)},
+			want:    30 * time.Second,
+		},
+		{
+			name:   "Primary rate limit",
+			status: http.StatusForbidden,
+			headers: map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.Itoa(1700000042),
+			},
+			want: 42 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				if attempts == 1 {
+					for key, value := range tt.headers {
+						w.Header().Set(key, value)
+					}
+
+					http.Error(w, `{"message": "rate limited"}`, tt.status)
+
+					return
+				}
+
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer testServer.Close()
+
+			var delays []time.Duration
+
+			client := &http.Client{Transport: newTestRetryTransport(DefaultRetryPolicy(), &delays)}
+
+			resp, err := client.Get(testServer.URL)
+			require.NoError(t, err)
+			defer closeBody(resp.Body)
+
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			require.Equal(t, []time.Duration{tt.want}, delays)
+		})
+	}
+}
+
+func TestRetryTransport_SecondaryRateLimitBody(t *testing.T) {
+	const message = `{"message": "You have exceeded a secondary rate limit. Please wait a few minutes before you try again."}`
+
+	var attempts int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			http.Error(w, message, http.StatusForbidden)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer testServer.Close()
+
+	var delays []time.Duration
+
+	client := &http.Client{Transport: newTestRetryTransport(DefaultRetryPolicy(), &delays)}
+
+	resp, err := client.Get(testServer.URL)
+	require.NoError(t, err)
+	defer closeBody(resp.Body)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, attempts)
+	require.Equal(t, []time.Duration{time.Minute}, delays)
+
+	// A policy that does not allow waiting a minute returns the response,
+	// whose body can still be read in full.
+	attempts = 0
+	delays = nil
+
+	policy := DefaultRetryPolicy()
+	policy.MaxWait = 30 * time.Second
+	client = &http.Client{Transport: newTestRetryTransport(policy, &delays)}
+
+	resp, err = client.Get(testServer.URL)
+	require.NoError(t, err)
+	defer closeBody(resp.Body)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, message+"\n", string(body))
+	require.Empty(t, delays)
+	require.ErrorIs(t, newHTTPStatusError(&http.Response{StatusCode: http.StatusForbidden, Body: io.NopCloser(strings.NewReader(message))}), ErrRateLimited)
+}
+
+func TestRetryTransport_DoesNotRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		status  int
+		headers map[string]string
+		body    string
+	}{
+		{name: "Not found", method: http.MethodGet, status: http.StatusNotFound},
+		{name: "Forbidden", method: http.MethodGet, status: http.StatusForbidden},
+		{name: "Forbidden with message", method: http.MethodGet, status: http.StatusForbidden, body: `{"message": "Resource not accessible by integration"}`},
+		{name: "POST", method: http.MethodPost, status: http.StatusBadGateway},
+		{
+			name:    "Wait longer than MaxWait",
+			method:  http.MethodGet,
+			status:  http.StatusTooManyRequests,
+			headers: map[string]string{"Retry-After": "3600"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				for key, value := range tt.headers {
+					w.Header().Set(key, value)
+				}
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer testServer.Close()
+
+			var delays []time.Duration
+
+			client := &http.Client{Transport: newTestRetryTransport(DefaultRetryPolicy(), &delays)}
+
+			req, err := http.NewRequest(tt.method, testServer.URL, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer closeBody(resp.Body)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			require.Equal(t, tt.status, resp.StatusCode)
+			require.Equal(t, tt.body, string(body))
+			require.Equal(t, 1, attempts)
+			require.Empty(t, delays)
+		})
+	}
+}
+
+func TestRetryTransport_ContextCanceledWhileWaiting(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	transport := NewRetryTransport(nil, DefaultRetryPolicy())
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		<-ctx.Done()
+
+		return ctx.Err()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL, nil)
+	require.NoError(t, err)
+
+	_, err = (&http.Client{Transport: transport}).Do(req)
+	require.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestRetryTransport_Nested(t *testing.T) {
+	var attempts int
+
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer testServer.Close()
+
+	var delays []time.Duration
+
+	inner := newTestRetryTransport(RetryPolicy{MaxAttempts: 3}, &delays)
+	outer := newTestRetryTransport(RetryPolicy{MaxAttempts: 2}, &delays)
+
+	// The outer transport only sees the inner one through a function, as
+	// with github.Client.WithAuthToken.
+	outer.Base = roundTripperFunc(inner.RoundTrip)
+
+	resp, err := (&http.Client{Transport: outer}).Get(testServer.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, 3, attempts)
+
+	attempts = 0
+	outer.overrideNested = true
+
+	resp, err = (&http.Client{Transport: outer}).Get(testServer.URL)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, 2, attempts)
+}
+
+// roundTripperFunc adapts a function to an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}